@@ -6,6 +6,7 @@ require (
 	github.com/hashicorp/hcl v1.0.0
 	github.com/jackc/pgx v3.6.2+incompatible
 	github.com/kenshaw/snaker v0.4.3
+	golang.org/x/tools v0.40.0
 )
 
 require (
@@ -16,5 +17,7 @@ require (
 	github.com/pkg/errors v0.9.1 // indirect
 	github.com/shopspring/decimal v1.4.0 // indirect
 	golang.org/x/crypto v0.46.0 // indirect
+	golang.org/x/mod v0.31.0 // indirect
+	golang.org/x/sync v0.19.0 // indirect
 	golang.org/x/text v0.33.0 // indirect
 )
@@ -4,7 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"flag"
-	"html/template"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
@@ -13,14 +13,16 @@ import (
 	"github.com/jackc/pgx"
 
 	"github.com/hashicorp/hcl"
+
+	"github.com/wttw/mro/mro"
 )
 
 var configFile string
 var initFiles bool
 var clean bool
+var check bool
 var defaultPackage string
-var c Config
-var db *pgx.Conn
+var jsonInput string
 
 func main() {
 	flag.Parse()
@@ -35,111 +37,132 @@ func main() {
 		log.Fatalf("Cannot read configuration file '%s': %s", configFile, err)
 	}
 
+	var c mro.Config
 	err = hcl.Unmarshal(cfg, &c)
 	if err != nil {
 		log.Fatalf("Failed to read configuration '%s': %s", configFile, err)
 	}
 
+	if c.TemplateParameters == nil {
+		c.TemplateParameters = map[string]interface{}{}
+	}
 	_, ok := c.TemplateParameters["package"]
 	if !ok {
 		c.TemplateParameters["package"] = defaultPackage
 	}
 
-	dbCfg, err := pgx.ParseConnectionString(c.ConnectionString)
-	if err != nil {
-		log.Fatalf("Invalid connection string in '%s': %s", configFile, err)
+	if clean {
+		// Only the manifest is needed to know what to delete
+		err = mro.NewRenderer(c, mro.Result{}).Clean()
+		if err != nil {
+			log.Fatalf("%s", err)
+		}
+		return
 	}
 
-	db, err = pgx.Connect(dbCfg)
-	if err != nil {
-		log.Fatalf("Failed to connect to database: %s", err)
+	var schema mro.Result
+	var schemaJSON []byte
+	if jsonInput != "" {
+		schema = readJSON(jsonInput)
+	} else {
+		schema = readDatabase(c)
+		if c.JsonOutput != "" {
+			schemaJSON, err = json.MarshalIndent(&schema, "", "  ")
+			if err != nil {
+				log.Fatalf("%s", err)
+			}
+		}
 	}
 
-	schema := introspect()
-	if c.JsonOutput != "" {
-		b, err := json.MarshalIndent(&schema, "", "  ")
-		if err != nil {
-			log.Fatalf("%s", err)
-		}
-		err = ioutil.WriteFile(c.JsonOutput, b, 0644)
+	if schemaJSON != nil && !check {
+		err = ioutil.WriteFile(c.JsonOutput, schemaJSON, 0644)
 		if err != nil {
 			log.Fatalf("%s", err)
 		}
 	}
 
-	if clean {
-		wipeFiles(schema)
-		return
-	}
+	renderer := mro.NewRenderer(c, schema)
 
-	wipeFiles(schema)
+	files, err := renderer.Render()
+	if err != nil {
+		log.Fatalf("%s", err)
+	}
 
-	if c.EnumFilename != "" {
-		err = renderEnums(schema)
+	if check {
+		stale, err := renderer.Check(files)
 		if err != nil {
-			log.Fatalf("Failed to render enums: %s", err)
+			log.Fatalf("Failed to check generated files: %s", err)
 		}
-	}
-	if c.TableFilename != "" {
-		err = renderTables(schema)
-		if err != nil {
-			log.Fatalf("Failed to render tables: %s", err)
+		if schemaJSON != nil {
+			existing, err := ioutil.ReadFile(c.JsonOutput)
+			if err != nil || !bytes.Equal(existing, schemaJSON) {
+				stale = append(stale, c.JsonOutput)
+			}
 		}
+		if len(stale) > 0 {
+			fmt.Fprintf(os.Stderr, "Generated files are out of date:\n")
+			for _, filename := range stale {
+				fmt.Fprintf(os.Stderr, "  %s\n", filename)
+			}
+			os.Exit(1)
+		}
+		return
 	}
-}
 
-func init() {
-	cwd, _ := os.Getwd()
-
-	flag.StringVar(&configFile, "config", "mro.cfg", "Read configuration from this file")
-	flag.BoolVar(&clean, "clean", false, "Delete generated files")
-	flag.BoolVar(&initFiles, "bootstrap", false, "Initialize configuration files")
-	flag.StringVar(&defaultPackage, "package", path.Base(cwd), "Generate files for this package")
+	err = renderer.Write(files)
+	if err != nil {
+		log.Fatalf("Failed to write generated files: %s", err)
+	}
 }
 
-func tableFilename(t Table) string {
-	if c.TableFilename == "" {
-		return ""
+// readDatabase introspects the database given in the configuration file
+func readDatabase(c mro.Config) mro.Result {
+	dbCfg, err := pgx.ParseConnectionString(c.ConnectionString)
+	if err != nil {
+		log.Fatalf("Invalid connection string in '%s': %s", configFile, err)
 	}
-	filenameTemplate, err := template.New("filename").Parse(c.TableFilename)
+
+	db, err := pgx.Connect(dbCfg)
 	if err != nil {
-		log.Fatalf("Bad template for TableFilename: %s\n", err)
+		log.Fatalf("Failed to connect to database: %s", err)
 	}
-	var b bytes.Buffer
-	err = filenameTemplate.Execute(&b, t)
+	defer db.Close()
+
+	schema, err := mro.NewIntrospector(c, db).Introspect()
 	if err != nil {
-		log.Fatalf("TableFilename template failed: %s\n", err)
+		log.Fatalf("%s", err)
 	}
-	return b.String()
+	return schema
 }
 
-func enumFilename(e Enum) string {
-	if c.EnumFilename == "" {
-		return ""
-	}
-	filenameTemplate, err := template.New("filename").Parse(c.EnumFilename)
+// readJSON loads a result previously saved to JsonOutput, so that we
+// can generate code without access to the database
+func readJSON(filename string) mro.Result {
+	b, err := ioutil.ReadFile(filename)
 	if err != nil {
-		log.Fatalf("Bad template for EnumFilename: %s\n", err)
+		log.Fatalf("Cannot read '%s': %s", filename, err)
 	}
-	var b bytes.Buffer
-	err = filenameTemplate.Execute(&b, e)
+	var schema mro.Result
+	err = json.Unmarshal(b, &schema)
 	if err != nil {
-		log.Fatalf("EnumFilename template failed: %s\n", err)
+		log.Fatalf("Failed to read '%s': %s", filename, err)
 	}
-	return b.String()
-}
-
-func wipeFiles(r Result) {
-	for _, t := range r.Tables {
-		filename := tableFilename(t)
-		if filename != "" {
-			_ = os.Remove(filename)
-		}
-	}
-	for _, e := range r.Enums {
-		filename := enumFilename(e)
-		if filename != "" {
-			_ = os.Remove(filename)
+	// Snapshots from before tables could be renamed
+	for k, t := range schema.Tables {
+		if t.Alias == "" {
+			schema.Tables[k].Alias = t.Name
 		}
 	}
+	return schema
+}
+
+func init() {
+	cwd, _ := os.Getwd()
+
+	flag.StringVar(&configFile, "config", "mro.cfg", "Read configuration from this file")
+	flag.BoolVar(&clean, "clean", false, "Delete generated files")
+	flag.BoolVar(&check, "check", false, "Check that generated files are up to date, without changing them")
+	flag.BoolVar(&initFiles, "bootstrap", false, "Initialize configuration files")
+	flag.StringVar(&defaultPackage, "package", path.Base(cwd), "Generate files for this package")
+	flag.StringVar(&jsonInput, "from-json", "", "Generate files from this saved JsonOutput instead of the database")
 }
@@ -0,0 +1,77 @@
+// Package mro introspects a PostgreSQL database and generates Go code for it
+// from templates. It is what the mro command is built on.
+package mro
+
+import "path/filepath"
+
+// TableConfig holds the configuration for a single table
+type TableConfig struct {
+	IncludeColumns []string
+	ExcludeColumns []string
+	ColumnType     map[string]string
+	Rename         string
+}
+
+// SchemaConfig holds the output settings for the tables and types in a
+// single schema
+type SchemaConfig struct {
+	// Directory is where generated files go, relative to the current
+	// directory
+	Directory string
+	// Package is the Go package name, by default the last element of
+	// Directory
+	Package string
+	// ImportPath is how other generated packages import this one, by
+	// default based on the module path in the enclosing go.mod
+	ImportPath string
+}
+
+// Config holds all configuration, as serialized from mro.cfg
+type Config struct {
+	ConnectionString      string
+	IncludeTables         []string
+	ExcludeTables         []string
+	Default               TableConfig
+	Table                 map[string]TableConfig
+	Schema                map[string]SchemaConfig
+	Types                 map[string]string
+	NotNullTypes          map[string]string
+	Imports               map[string]string
+	JsonOutput            string
+	Manifest              string
+	EnumFilename          string
+	EnumTemplate          string
+	CompositeFilename     string
+	CompositeTemplate     string
+	DomainFilename        string
+	DomainTemplate        string
+	TableFilename         string
+	TableTemplate         string
+	TemplateParameters    map[string]interface{}
+	GeneratePKQueries     bool
+	GenerateUniqueQueries bool
+	GenerateFKQueries     bool
+	GenerateDomainTypes   bool
+	Queries               map[string]string
+	ReservedNames         []string
+	PostProcess           []string
+	Workers               int
+}
+
+// Output returns the output settings for a schema, with defaults filled
+// in. Schemas without settings of their own are generated in the current
+// directory, with the package from TemplateParameters.
+func (c Config) Output(schema string) SchemaConfig {
+	sc, ok := c.Schema[schema]
+	if !ok || sc.Directory == "" {
+		sc.Directory = "."
+		if sc.Package == "" {
+			sc.Package, _ = c.TemplateParameters["package"].(string)
+		}
+	}
+	sc.Directory = filepath.Clean(sc.Directory)
+	if sc.Package == "" {
+		sc.Package = filepath.Base(sc.Directory)
+	}
+	return sc
+}
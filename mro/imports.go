@@ -0,0 +1,144 @@
+package mro
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/imports"
+)
+
+// stdImports are the import paths of standard library packages that the Go
+// types in a type map are likely to use, by package name
+var stdImports = map[string]string{
+	"big":    "math/big",
+	"driver": "database/sql/driver",
+	"json":   "encoding/json",
+	"net":    "net",
+	"netip":  "net/netip",
+	"sql":    "database/sql",
+	"time":   "time",
+}
+
+// importPaths returns the import path to use for each package name that
+// generated code may refer to without a template importing it
+func (r *Renderer) importPaths() map[string]string {
+	paths := make(map[string]string, len(stdImports)+len(r.config.Imports))
+	for name, path := range stdImports {
+		paths[name] = path
+	}
+	for name, path := range r.config.Imports {
+		paths[name] = path
+	}
+	return paths
+}
+
+// fixImports removes the imports that src doesn't use, and adds those it
+// does from paths, then formats it. It works from src and paths alone, so
+// it doesn't need the go command and gives the same result everywhere.
+func fixImports(filename string, src []byte, paths map[string]string) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	// Package names are the only identifiers on the left of a selector that
+	// the parser can't resolve to a declaration in this file. Anything else
+	// declared elsewhere in the package is left for the compiler.
+	used := map[string]bool{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok && id.Obj == nil {
+				used[id.Name] = true
+			}
+		}
+		return true
+	})
+
+	// Duplicates are deleted along with the import they duplicate, so the
+	// imports that are kept are put back afterwards if need be
+	type spec struct{ name, path string }
+	imported := map[string]bool{}
+	keep := []spec{}
+	unwanted := []spec{}
+	for _, is := range file.Imports {
+		path, err := strconv.Unquote(is.Path.Value)
+		if err != nil {
+			return nil, err
+		}
+		name := importName(path)
+		explicit := ""
+		if is.Name != nil {
+			name, explicit = is.Name.Name, is.Name.Name
+		}
+		if name == "_" || name == "." {
+			continue
+		}
+		if !used[name] || imported[name] {
+			unwanted = append(unwanted, spec{explicit, path})
+			continue
+		}
+		imported[name] = true
+		keep = append(keep, spec{explicit, path})
+	}
+	for _, u := range unwanted {
+		astutil.DeleteNamedImport(fset, file, u.name, u.path)
+	}
+	for _, k := range keep {
+		astutil.AddNamedImport(fset, file, k.name, k.path)
+	}
+
+	missing := []string{}
+	for name := range used {
+		if !imported[name] && paths[name] != "" {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	for _, name := range missing {
+		if importName(paths[name]) == name {
+			astutil.AddImport(fset, file, paths[name])
+		} else {
+			astutil.AddNamedImport(fset, file, name, paths[name])
+		}
+	}
+
+	var buf bytes.Buffer
+	err = format.Node(&buf, fset, file)
+	if err != nil {
+		return nil, err
+	}
+	// Sort and group the imports the way goimports does. With FormatOnly
+	// set it doesn't look for packages, so it doesn't run the go command.
+	return imports.Process(filename, buf.Bytes(), &imports.Options{
+		FormatOnly: true,
+		Comments:   true,
+		TabIndent:  true,
+		TabWidth:   8,
+	})
+}
+
+// importName guesses the package name for an import path, the same way
+// goimports does: the last element, without any major version suffix,
+// "go-" prefix, or anything after a "." or "-"
+func importName(path string) string {
+	elems := strings.Split(path, "/")
+	name := elems[len(elems)-1]
+	if len(elems) > 1 && len(name) > 1 && name[0] == 'v' {
+		if _, err := strconv.Atoi(name[1:]); err == nil {
+			name = elems[len(elems)-2]
+		}
+	}
+	name = strings.TrimPrefix(name, "go-")
+	if i := strings.IndexAny(name, ".-"); i != -1 {
+		name = name[:i]
+	}
+	return name
+}
@@ -0,0 +1,58 @@
+package mro
+
+import (
+	"testing"
+)
+
+func TestFixImports(t *testing.T) {
+	src := `package test
+
+import (
+	"context"
+	"strings"
+	"time"
+	"time"
+	"github.com/jackc/pgx/v5"
+)
+
+type Row struct {
+	ID      uuid.UUID
+	When    time.Time
+	Balance decimal.Decimal
+	Other   other.Thing
+}
+
+func Get(ctx context.Context, db pgx.Tx) {}
+`
+	want := `package test
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	uuid "github.com/satori/go.uuid"
+	"github.com/shopspring/decimal"
+)
+
+type Row struct {
+	ID      uuid.UUID
+	When    time.Time
+	Balance decimal.Decimal
+	Other   other.Thing
+}
+
+func Get(ctx context.Context, db pgx.Tx) {}
+`
+	got, err := fixImports("test.go", []byte(src), map[string]string{
+		"uuid":    "github.com/satori/go.uuid",
+		"decimal": "github.com/shopspring/decimal",
+		"strings": "strings",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,1951 @@
+package mro
+
+import (
+	"fmt"
+	"go/token"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx"
+	"github.com/kenshaw/snaker"
+)
+
+// Field describes a single column of a table, and is also abused to store
+// query parameters
+type Field struct {
+	Name       string
+	Position   int
+	Type       string
+	NotNull    bool
+	Array      bool
+	GoType     string
+	Visible    bool
+	TypeID     uint32
+	HasDefault bool
+	// Generated is set for generated columns, which can't be written to
+	Generated bool
+	// IdentityAlways is set for GENERATED ALWAYS AS IDENTITY columns, which
+	// can only be written to with OVERRIDING SYSTEM VALUE
+	IdentityAlways bool
+}
+
+// Unique describes a unique index
+type Unique struct {
+	Name       string
+	PrimaryKey bool
+	Columns    []string
+}
+
+// ForeignKey describes a foreign key
+type ForeignKey struct {
+	Name    string
+	Columns []string
+	// ForeignSchema, ForeignTable and ForeignColumns are what the foreign
+	// key refers to, which may be a table that isn't included
+	ForeignSchema  string
+	ForeignTable   string
+	ForeignColumns []string
+	// ForeignPackage is the name of the Go package the foreign table is
+	// generated in, or empty if it's the same package as this table
+	ForeignPackage string
+	// ParentMethod is the name of the method that loads the row the foreign
+	// key refers to, or empty if there isn't one
+	ParentMethod string
+	// BatchFunction is the name of the function that loads the rows a slice
+	// of rows refer to, or empty if there isn't one
+	BatchFunction string
+}
+
+// Reference describes a foreign key in another table that refers to a table
+type Reference struct {
+	Name           string
+	Schema         string
+	Table          string
+	Columns        []string
+	ForeignColumns []string
+	// ChildrenMethod is the name of the method that loads the rows that
+	// refer to a row
+	ChildrenMethod string
+	// BatchFunction is the name of the function that loads the rows that
+	// refer to each of a slice of rows, or empty if there isn't one
+	BatchFunction string
+}
+
+// Query describes a SQL query
+type Query struct {
+	Name          string
+	Query         string
+	OriginalQuery string
+	Fields        []Field
+	Parameters    []Field
+	SingleRow     bool
+	// ResultType is the name of the struct generated for rows returned by
+	// the query, or empty if they're rows of the table it belongs to
+	ResultType string
+	// Exec is set for statements that don't return any rows
+	Exec bool
+}
+
+// Table describes a database table
+type Table struct {
+	OID    uint32
+	Name   string
+	Schema string
+	// Alias is the name used for Go identifiers and filenames, which is
+	// the same as Name unless the table is renamed in the configuration
+	Alias   string
+	Type    string
+	Fields  []Field
+	Indexes []Unique
+	Primary Unique
+	// PrimaryFields are the columns of the primary key, if there is one
+	PrimaryFields []Field
+	// IDField is the column of a single column primary key with a default
+	IDField     Field
+	Queries     []Query
+	ForeignKeys []ForeignKey
+	// References are the foreign keys in other tables that refer to this one
+	References []Reference
+}
+
+// Enum describes a database enum type
+type Enum struct {
+	OID    uint32
+	Name   string
+	Schema string
+	Labels []string
+}
+
+// Composite describes a database composite type
+type Composite struct {
+	OID    uint32
+	Name   string
+	Schema string
+	Fields []Field
+}
+
+// Domain describes a database domain type
+type Domain struct {
+	OID        uint32
+	Name       string
+	Schema     string
+	BaseType   string
+	BaseTypeID uint32
+	NotNull    bool
+	// GoType is the Go type that a not null value of the base type maps to
+	GoType string
+	// Checks are the domain's CHECK constraints, as "CHECK (VALUE ...)"
+	Checks []string
+}
+
+// Result is all the information generated from database introspection
+type Result struct {
+	Tables     []Table
+	Enums      []Enum
+	Composites []Composite
+	Domains    []Domain
+	GoNames    map[string]string
+}
+
+// Introspector reads the structure of a database, as selected by a
+// configuration, and builds the Result that code is generated from
+type Introspector struct {
+	config Config
+	db     *pgx.Conn
+
+	// Logf reports problems that don't stop introspection, such as types
+	// that can't be mapped. It defaults to log.Printf.
+	Logf func(format string, v ...interface{})
+
+	// In case we need to do something version specific
+	dbVersion int
+
+	// type mappings, pulled in from config
+	nullType    map[uint32]string
+	notNullType map[uint32]string
+
+	// enums we've seen in a query or table OID -> name
+	seenEnums map[uint32]string
+
+	// all enums, extracted from pg_type OID -> name
+	allEnums map[uint32]string
+
+	// composite types we've seen in a query, table or composite OID -> name
+	seenComposites map[uint32]string
+
+	// all composite types, extracted from pg_type OID -> name
+	allComposites map[uint32]string
+
+	// domains we've seen in a query, table or composite OID -> domain
+	seenDomains map[uint32]Domain
+
+	// all domains, extracted from pg_type OID -> domain
+	allDomains map[uint32]Domain
+
+	// array types of domains, mapped to the array type of their base type
+	domainArrays map[uint32]uint32
+
+	// schemas of enums and composite types OID -> schema
+	typeSchemas map[uint32]string
+
+	// output directories that code generated in one output directory uses
+	// types from
+	imports map[string]map[string]bool
+
+	result  Result
+	queries []namedQuery
+	names   *namer
+}
+
+// NewIntrospector creates an Introspector that reads the database db is
+// connected to
+func NewIntrospector(config Config, db *pgx.Conn) *Introspector {
+	return &Introspector{
+		config: config,
+		db:     db,
+		Logf:   log.Printf,
+	}
+}
+
+// Introspect does all the database work needed to create our Result
+// object. Each call starts afresh, so an Introspector can be reused.
+func (in *Introspector) Introspect() (Result, error) {
+	in.nullType = map[uint32]string{}
+	in.notNullType = map[uint32]string{}
+	in.seenEnums = map[uint32]string{}
+	in.allEnums = map[uint32]string{}
+	in.seenComposites = map[uint32]string{}
+	in.allComposites = map[uint32]string{}
+	in.seenDomains = map[uint32]Domain{}
+	in.allDomains = map[uint32]Domain{}
+	in.domainArrays = map[uint32]uint32{}
+	in.typeSchemas = map[uint32]string{}
+	in.imports = map[string]map[string]bool{}
+	in.result = Result{}
+	in.queries = nil
+	in.names = newNamer(nil)
+
+	// Get the version of the database we're talking to
+	var versionString string
+	err := in.db.QueryRow(`select current_setting('server_version_num')`).Scan(&versionString)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to read server version: %s", err)
+	}
+	in.dbVersion, err = strconv.Atoi(versionString)
+	if err != nil {
+		return Result{}, fmt.Errorf("bad version '%s': %s", versionString, err)
+	}
+
+	steps := []func() error{
+		// Get a list of enums that are in the database
+		in.listEnums,
+		// Get a list of composite types that are in the database
+		in.listComposites,
+		// Get a list of domains that are in the database
+		in.listDomains,
+		// Fetch the postgresql-to-Go type maps from the configuration file
+		in.readTypes,
+		// Get the structure of tables we're interested in
+		in.readTables,
+		// Read SQL queries from configuration file
+		in.readQueries,
+		// Find unique indexes for each table, synthesize queries
+		in.readIndexes,
+		// Find foreign keys for each table, synthesize queries
+		in.readFKs,
+		// Name the methods that load rows related by foreign keys
+		in.nameRelations,
+		// Generate types for each SQL query
+		in.generateQueries,
+		// Load the fields of composite types we've seen in use in a table or
+		// query
+		in.loadComposites,
+		// Load the check constraints of domains we've seen in use
+		in.loadDomains,
+		// Load the labels of enums we've seen in use in a table, query or
+		// composite type
+		in.loadEnums,
+		// Drop loaders for foreign keys that would make packages import
+		// each other
+		in.checkImports,
+	}
+	for _, step := range steps {
+		err = step()
+		if err != nil {
+			return Result{}, err
+		}
+	}
+
+	// Remove columns that aren't visible, either ignored or deleted
+	in.removeColumns()
+
+	// Try and avoid name clashes between parameters and internal template variables
+	in.fixQueryParameters()
+
+	// Record the Go names we've chosen, so that rendering from a saved
+	// copy of the result makes the same choices
+	in.result.GoNames = in.names.mapping()
+
+	return in.result, nil
+}
+
+var nonLetterRe = regexp.MustCompile(`[^\pL_]`)
+
+// namer chooses Go names for database names, remembering its choices so
+// that the same name always maps to the same identifier and two names
+// never map to the same one
+type namer struct {
+	nameMapping     map[string]string
+	seenNameMapping map[string]struct{}
+	// reserved are the names claimed with reserve
+	reserved map[string]struct{}
+}
+
+// newNamer creates a namer, primed with names chosen earlier
+func newNamer(names map[string]string) *namer {
+	n := &namer{
+		nameMapping:     map[string]string{},
+		seenNameMapping: map[string]struct{}{},
+		reserved:        map[string]struct{}{},
+	}
+	for k, v := range names {
+		n.nameMapping[k] = v
+		n.seenNameMapping[v] = struct{}{}
+	}
+	return n
+}
+
+// goname converts a snake_case name to a GoStyle name
+// It also maps invalid names to valid ones and avoids collisions
+func (n *namer) goname(s string) string {
+	r, ok := n.nameMapping[s]
+	if ok {
+		return r
+	}
+
+	r = snaker.SnakeToCamel(nonLetterRe.ReplaceAllString(s, "_x"))
+
+	_, ok = n.seenNameMapping[r]
+	if ok {
+		i := 2
+		for {
+			u := fmt.Sprintf("%s%d", r, i)
+			_, ok = n.seenNameMapping[u]
+			if !ok {
+				r = u
+				break
+			}
+			i++
+		}
+	}
+
+	n.seenNameMapping[r] = struct{}{}
+	n.nameMapping[s] = r
+	return r
+}
+
+// reserve claims a Go name for a type that isn't based on a database name,
+// so that nothing named after a database name is given it later. It returns
+// false if the name has already been reserved. Names already given to
+// database names are left to the caller to check, as only some of them,
+// such as those of types, clash with it.
+func (n *namer) reserve(r string) bool {
+	if _, ok := n.reserved[r]; ok {
+		return false
+	}
+	n.reserved[r] = struct{}{}
+	n.seenNameMapping[r] = struct{}{}
+	n.nameMapping[r] = r
+	return true
+}
+
+// named returns the Go name already chosen for a database name, if there
+// is one
+func (n *namer) named(s string) (string, bool) {
+	r, ok := n.nameMapping[s]
+	return r, ok
+}
+
+// mapping returns a copy of the names chosen so far
+func (n *namer) mapping() map[string]string {
+	m := make(map[string]string, len(n.nameMapping))
+	for k, v := range n.nameMapping {
+		m[k] = v
+	}
+	return m
+}
+
+// sortedKeys returns the keys of a map in sorted order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// makeRegexp converts a slice of glob patterns to a regexp
+func makeRegexp(globs []string) string {
+	parts := make([]string, len(globs))
+	for k, v := range globs {
+		parts[k] = strings.Replace(regexp.QuoteMeta(v), `\*`, `.*`, -1)
+	}
+	return "^(" + strings.Join(parts, "|") + ")$"
+}
+
+func (in *Introspector) removeColumns() {
+	for ti, table := range in.result.Tables {
+		newFields := []Field{}
+		for _, f := range table.Fields {
+			if f.Visible {
+				newFields = append(newFields, f)
+			}
+		}
+		table.Fields = newFields
+		in.result.Tables[ti] = table
+	}
+}
+
+// readTypes takes the type mappings from the configuration file
+// sanity checks them and normalizes them
+func (in *Introspector) readTypes() error {
+	canonicalTypes, err := in.canonicalTypes()
+	if err != nil {
+		return err
+	}
+
+	for _, k := range sortedKeys(in.config.NotNullTypes) {
+		v := in.config.NotNullTypes[k]
+		if k == "*" {
+			in.notNullType[0] = v
+			continue
+		}
+		canonicalType, ok := canonicalTypes[k]
+		if !ok {
+			in.Logf("Failed to canonicalize type '%s': no such type", k)
+			continue
+		}
+		al, ok := in.notNullType[canonicalType]
+		if ok {
+			// We have an alias
+			if al != v {
+				return fmt.Errorf("Postgresql type '%s' is mapped two different ways, to '%s' and '%s'", k, v, al)
+			}
+		} else {
+			in.notNullType[canonicalType] = v
+		}
+	}
+
+	for _, k := range sortedKeys(in.config.Types) {
+		v := in.config.Types[k]
+		if k == "*" {
+			in.nullType[0] = v
+			continue
+		}
+		canonicalType, ok := canonicalTypes[k]
+		if !ok {
+			in.Logf("Failed to canonicalize type '%s': no such type", k)
+			continue
+		}
+		al, ok := in.nullType[canonicalType]
+		if ok {
+			// We have an alias
+			if al != v {
+				return fmt.Errorf("Postgresql type '%s' is mapped two different ways, to '%s' and '%s'", k, v, al)
+			}
+		} else {
+			in.nullType[canonicalType] = v
+		}
+		// A nullable type can be used for a not null field, so fill in gaps ...
+		_, ok = in.notNullType[canonicalType]
+		if !ok {
+			in.notNullType[canonicalType] = v
+		}
+	}
+	return nil
+}
+
+// canonicalTypes looks up the oids of all the types named in the type
+// mappings, in a single query. Names that aren't types are left out.
+func (in *Introspector) canonicalTypes() (map[string]uint32, error) {
+	names := []string{}
+	for _, m := range []map[string]string{in.config.NotNullTypes, in.config.Types} {
+		for k := range m {
+			if k != "*" {
+				names = append(names, k)
+			}
+		}
+	}
+	canonicalTypes := map[string]uint32{}
+	if len(names) == 0 {
+		return canonicalTypes, nil
+	}
+
+	q, err := in.db.Query(`select x, coalesce(to_regtype(x)::oid, 0) from unnest($1::text[]) x`, names)
+	if err != nil {
+		return nil, fmt.Errorf("failed to canonicalize types: %s", err)
+	}
+	defer q.Close()
+	for q.Next() {
+		var name string
+		var oid uint32
+		err = q.Scan(&name, &oid)
+		if err != nil {
+			return nil, err
+		}
+		if oid != 0 {
+			canonicalTypes[name] = oid
+		}
+	}
+	return canonicalTypes, q.Err()
+}
+
+// readTables reads all the tables
+func (in *Introspector) readTables() error {
+	// Work on copies, so the configuration is left as it was
+	include := append([]string{}, in.config.IncludeTables...)
+	if len(include) == 0 {
+		include = []string{"public.*"}
+	}
+	for k, v := range include {
+		if !strings.Contains(v, ".") {
+			include[k] = "public." + v
+		}
+	}
+
+	exclude := append([]string{}, in.config.ExcludeTables...)
+	for k, v := range exclude {
+		if !strings.Contains(v, ".") {
+			exclude[k] = "*." + v
+		}
+	}
+
+	const tableSQL = `select c.oid, c.relkind::text, c.relname, n.nspname` +
+		` from pg_class c, pg_namespace n` +
+		` where c.relkind in ('r', 'v', 'm') and` +
+		` n.nspname || '.' || c.relname ~ $1 and` +
+		` n.nspname || '.' || c.relname !~ $2 and` +
+		` n.oid = c.relnamespace` +
+		` order by n.nspname, c.relname`
+
+	q, err := in.db.Query(tableSQL, makeRegexp(include), makeRegexp(exclude))
+	if err != nil {
+		return err
+	}
+	defer q.Close()
+
+	for q.Next() {
+		t := Table{}
+		err = q.Scan(&t.OID, &t.Type, &t.Name, &t.Schema)
+		if err != nil {
+			return err
+		}
+
+		in.result.Tables = append(in.result.Tables, t)
+	}
+	q.Close()
+
+	columns, err := in.readColumns()
+	if err != nil {
+		return err
+	}
+
+	for k, t := range in.result.Tables {
+		conf := in.tableConfig(t)
+		// Go identifiers and filenames are based on the table name, unless
+		// the table has been renamed in the configuration
+		t.Alias = t.Name
+		if conf.Rename != "" {
+			t.Alias = conf.Rename
+		}
+
+		t.Fields = in.tableColumns(columns[t.OID], t.Name, t.Schema, conf)
+		in.result.Tables[k] = t
+	}
+
+	// Tables with the same name in different schemas, such as billing.account
+	// and auth.account, are told apart by prefixing the schema, unless
+	// they're generated in different packages
+	aliases := map[string]int{}
+	for _, t := range in.result.Tables {
+		aliases[in.config.Output(t.Schema).Directory+"/"+t.Alias]++
+	}
+	for k, t := range in.result.Tables {
+		if aliases[in.config.Output(t.Schema).Directory+"/"+t.Alias] > 1 {
+			in.result.Tables[k].Alias = t.Schema + "_" + t.Alias
+		}
+	}
+
+	return nil
+}
+
+// tableConfig returns the configuration for a table, falling back to the
+// Default configuration
+func (in *Introspector) tableConfig(t Table) TableConfig {
+	conf, ok := in.config.Table[t.Schema+"."+t.Name]
+	if !ok {
+		conf, ok = in.config.Table[t.Name]
+	}
+	if !ok {
+		// Default doesn't rename anything
+		conf = in.config.Default
+		conf.Rename = ""
+	}
+	return conf
+}
+
+// qualify returns the name that something generated for schema "to" has in
+// code generated for schema "from", prefixed with its package if they're
+// generated in different packages
+func (in *Introspector) qualify(from string, to string, name string) string {
+	f := in.config.Output(from)
+	t := in.config.Output(to)
+	if f.Directory == t.Directory {
+		return name
+	}
+	if in.imports[f.Directory] == nil {
+		in.imports[f.Directory] = map[string]bool{}
+	}
+	in.imports[f.Directory][t.Directory] = true
+	return t.Package + "." + name
+}
+
+// seenType looks up a type in all, and if it's there records that we've
+// seen it in use
+func seenType(seen map[uint32]string, all map[uint32]string, oid uint32) (string, bool) {
+	name, ok := seen[oid]
+	if ok {
+		return name, true
+	}
+	name, ok = all[oid]
+	if ok {
+		seen[oid] = name
+	}
+	return name, ok
+}
+
+// goType returns the Go type that a postgresql type oid maps to, in code
+// generated for schema
+func (in *Introspector) goType(oid uint32, notnull bool, typename string, tablename string, schema string) string {
+	var ok bool
+	var gt string
+	if notnull {
+		gt, ok = in.notNullType[oid]
+		if ok {
+			return gt
+		}
+	}
+	gt, ok = in.nullType[oid]
+	if ok {
+		return gt
+	}
+
+	// The enum and composite templates generate a NullX type for nullable
+	// columns
+	enumname, ok := seenType(in.seenEnums, in.allEnums, oid)
+	if ok {
+		if notnull {
+			return in.qualify(schema, in.typeSchemas[oid], in.names.goname(enumname))
+		}
+		return in.qualify(schema, in.typeSchemas[oid], "Null"+in.names.goname(enumname))
+	}
+
+	compositename, ok := seenType(in.seenComposites, in.allComposites, oid)
+	if ok {
+		if notnull {
+			return in.qualify(schema, in.typeSchemas[oid], in.names.goname(compositename))
+		}
+		return in.qualify(schema, in.typeSchemas[oid], "Null"+in.names.goname(compositename))
+	}
+
+	// Domains use the mapping for their base type, unless we're generating
+	// a named type for each of them
+	domain, ok := in.seenDomain(oid)
+	if ok {
+		if in.config.GenerateDomainTypes && basicType(domain.GoType) {
+			if notnull || domain.NotNull {
+				return in.qualify(schema, domain.Schema, in.names.goname(domain.Name))
+			}
+			return in.qualify(schema, domain.Schema, "Null"+in.names.goname(domain.Name))
+		}
+		return in.goType(domain.BaseTypeID, notnull || domain.NotNull, domain.BaseType, tablename, schema)
+	}
+	basearray, ok := in.domainArrays[oid]
+	if ok {
+		return in.goType(basearray, notnull, typename, tablename, schema)
+	}
+
+	if notnull {
+		gt, ok = in.notNullType[0]
+		if ok {
+			in.Logf("Using fallback type for type %s in %s\n", typename, tablename)
+			return gt
+		}
+	}
+
+	gt, ok = in.nullType[0]
+	if ok {
+		in.Logf("Using fallback type for type %s in %s\n", typename, tablename)
+		return gt
+	}
+
+	in.Logf("Couldn't translate type %s in %s\n", typename, tablename)
+	return "?unknown?"
+}
+
+// tableOIDs returns the oids of all the tables we've read, for
+// "= any($1)" queries
+func (in *Introspector) tableOIDs() []int64 {
+	oids := make([]int64, len(in.result.Tables))
+	for k, t := range in.result.Tables {
+		oids[k] = int64(t.OID)
+	}
+	return oids
+}
+
+// int64OIDs converts oids for "= any($1)" queries
+func int64OIDs(oids []uint32) []int64 {
+	ret := make([]int64, len(oids))
+	for k, oid := range oids {
+		ret[k] = int64(oid)
+	}
+	return ret
+}
+
+// readColumns reads the columns of all tables, in a single query, and
+// returns them grouped by table oid. Whether they're visible and which Go
+// types they map to is left for tableColumns.
+func (in *Introspector) readColumns() (map[uint32][]Field, error) {
+	// Identity columns were added in 11.0, we treat them as having a
+	// default. Generated columns were added in 12.0.
+	hasDefault := `a.atthasdef`
+	identityAlways := `false`
+	generated := `false`
+	if in.dbVersion >= 110000 {
+		hasDefault = `a.atthasdef or a.attidentity <> ''`
+		identityAlways = `a.attidentity = 'a'`
+	}
+	if in.dbVersion >= 120000 {
+		generated = `a.attgenerated <> ''`
+	}
+
+	// Explicitly pass NULL instead of atttypmod to format_type as we
+	// don't _really_ care about max length, etc
+	attrSQL := `select a.attrelid, a.attnum, a.attname, format_type(a.atttypid, NULL),` +
+		` a.attnotnull, a.attndims <> 0, not a.attisdropped,` +
+		` a.atttypid, ` + hasDefault + `, ` + generated + `, ` + identityAlways +
+		` from pg_attribute a` +
+		` where a.attrelid = any($1::int8[]::oid[]) and a.attnum > 0` +
+		` order by a.attrelid, a.attnum asc`
+
+	q, err := in.db.Query(attrSQL, in.tableOIDs())
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+
+	columns := map[uint32][]Field{}
+	for q.Next() {
+		var oid uint32
+		f := Field{}
+		err = q.Scan(&oid, &f.Position, &f.Name, &f.Type, &f.NotNull, &f.Array, &f.Visible, &f.TypeID, &f.HasDefault, &f.Generated, &f.IdentityAlways)
+		if err != nil {
+			return nil, err
+		}
+		columns[oid] = append(columns[oid], f)
+	}
+	return columns, q.Err()
+}
+
+// tableColumns decides which of the columns of a single table are visible,
+// and maps them to Go types
+func (in *Introspector) tableColumns(columns []Field, tableName string, schema string, conf TableConfig) []Field {
+	include := conf.IncludeColumns
+	if len(include) == 0 {
+		include = []string{"*"}
+	}
+	includeRe := regexp.MustCompile("(?i)" + makeRegexp(include))
+	excludeRe := regexp.MustCompile("(?i)" + makeRegexp(conf.ExcludeColumns))
+
+	ret := []Field{}
+	for _, f := range columns {
+		f.Visible = f.Visible && includeRe.MatchString(f.Name) && !excludeRe.MatchString(f.Name)
+
+		if f.Visible {
+			// Only look at the type of a field if we're not ignoring it
+			colType := f.Type
+			if f.Array {
+				colType = colType + "[]"
+			}
+
+			// table specific override
+			gotype, ok := conf.ColumnType[f.Name]
+			if !ok {
+				gotype = in.goType(f.TypeID, f.NotNull, colType, tableName, schema)
+			}
+
+			f.GoType = gotype
+		}
+		ret = append(ret, f)
+	}
+	return ret
+}
+
+// listEnums loads a list of all enum types
+func (in *Introspector) listEnums() error {
+	q, err := in.db.Query(`select t.oid, t.typname, n.nspname from pg_type t, pg_namespace n` +
+		` where t.typtype = 'e' and n.oid = t.typnamespace`)
+	if err != nil {
+		return err
+	}
+	defer q.Close()
+	for q.Next() {
+		var oid uint32
+		var name, schema string
+		err = q.Scan(&oid, &name, &schema)
+		if err != nil {
+			return err
+		}
+		in.allEnums[oid] = name
+		in.typeSchemas[oid] = schema
+	}
+	return nil
+}
+
+// listDomains loads a list of all domains, and the array types of their
+// base types
+func (in *Introspector) listDomains() error {
+	q, err := in.db.Query(`select t.oid, t.typname, n.nspname, t.typbasetype,` +
+		` format_type(t.typbasetype, NULL), t.typnotnull, t.typarray, b.typarray` +
+		` from pg_type t, pg_type b, pg_namespace n` +
+		` where t.typtype = 'd' and b.oid = t.typbasetype and n.oid = t.typnamespace`)
+	if err != nil {
+		return err
+	}
+	defer q.Close()
+	for q.Next() {
+		var d Domain
+		var array, basearray uint32
+		err = q.Scan(&d.OID, &d.Name, &d.Schema, &d.BaseTypeID, &d.BaseType, &d.NotNull, &array, &basearray)
+		if err != nil {
+			return err
+		}
+		in.allDomains[d.OID] = d
+		if array != 0 && basearray != 0 {
+			in.domainArrays[array] = basearray
+		}
+	}
+	return nil
+}
+
+// seenDomain looks up a domain, and if it's there records that we've seen
+// it in use, along with the Go type of its base type
+func (in *Introspector) seenDomain(oid uint32) (Domain, bool) {
+	d, ok := in.seenDomains[oid]
+	if ok {
+		return d, true
+	}
+	d, ok = in.allDomains[oid]
+	if !ok {
+		return d, false
+	}
+	d.GoType = in.goType(d.BaseTypeID, true, d.BaseType, d.Name, d.Schema)
+	in.seenDomains[oid] = d
+	return d, true
+}
+
+// basicTypes are the Go types that named types for domains can be based on.
+// A named type doesn't have the methods of the type it's based on, so the
+// driver can only read and write it if it's one of these.
+var basicTypes = map[string]bool{
+	"bool": true, "string": true, "[]byte": true,
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true,
+	"float32": true, "float64": true,
+}
+
+// basicType checks whether a named type for a domain can be based on a Go
+// type
+func basicType(goType string) bool {
+	return basicTypes[goType]
+}
+
+// loadDomains loads the check constraints of the domains we've seen in use
+func (in *Introspector) loadDomains() error {
+	oids := make([]uint32, 0, len(in.seenDomains))
+	for oid := range in.seenDomains {
+		oids = append(oids, oid)
+	}
+	sort.Slice(oids, func(i, j int) bool {
+		if in.seenDomains[oids[i]].Name != in.seenDomains[oids[j]].Name {
+			return in.seenDomains[oids[i]].Name < in.seenDomains[oids[j]].Name
+		}
+		return oids[i] < oids[j]
+	})
+	if len(oids) == 0 {
+		return nil
+	}
+
+	q, err := in.db.Query(`select contypid, pg_get_constraintdef(oid) from pg_constraint`+
+		` where contypid = any($1::int8[]::oid[]) and contype = 'c'`+
+		` order by contypid, conname`, int64OIDs(oids))
+	if err != nil {
+		return err
+	}
+	defer q.Close()
+	checks := map[uint32][]string{}
+	for q.Next() {
+		var oid uint32
+		var check string
+		err = q.Scan(&oid, &check)
+		if err != nil {
+			return err
+		}
+		checks[oid] = append(checks[oid], check)
+	}
+	if q.Err() != nil {
+		return q.Err()
+	}
+
+	for _, oid := range oids {
+		d := in.seenDomains[oid]
+		if in.config.GenerateDomainTypes && !basicType(d.GoType) {
+			in.Logf("Using %s for domain %s, as a named type based on it couldn't be read or written\n", d.GoType, d.Name)
+		}
+		d.Checks = append([]string{}, checks[oid]...)
+		in.result.Domains = append(in.result.Domains, d)
+	}
+	return nil
+}
+
+// listComposites loads a list of all composite types, other than those
+// that are the row types of tables
+func (in *Introspector) listComposites() error {
+	q, err := in.db.Query(`select t.oid, t.typname, n.nspname from pg_type t, pg_class c, pg_namespace n` +
+		` where t.typtype = 'c' and t.typrelid = c.oid and c.relkind = 'c' and n.oid = t.typnamespace`)
+	if err != nil {
+		return err
+	}
+	defer q.Close()
+	for q.Next() {
+		var oid uint32
+		var name, schema string
+		err = q.Scan(&oid, &name, &schema)
+		if err != nil {
+			return err
+		}
+		in.allComposites[oid] = name
+		in.typeSchemas[oid] = schema
+	}
+	return nil
+}
+
+// loadComposites loads the fields of composite types that we've seen in use.
+// Those fields may use composite types we've not seen yet, so keep going
+// until there are no more.
+func (in *Introspector) loadComposites() error {
+	loaded := map[uint32]struct{}{}
+	for {
+		oids := []uint32{}
+		for oid := range in.seenComposites {
+			if _, ok := loaded[oid]; !ok {
+				oids = append(oids, oid)
+			}
+		}
+		if len(oids) == 0 {
+			break
+		}
+		sort.Slice(oids, func(i, j int) bool { return oids[i] < oids[j] })
+
+		fields, err := in.readCompositeFields(oids)
+		if err != nil {
+			return err
+		}
+		for _, oid := range oids {
+			loaded[oid] = struct{}{}
+			ct := Composite{
+				OID:    oid,
+				Name:   in.seenComposites[oid],
+				Schema: in.typeSchemas[oid],
+				Fields: []Field{},
+			}
+			for _, f := range fields[oid] {
+				colType := f.Type
+				if f.Array {
+					colType = colType + "[]"
+				}
+				f.GoType = in.goType(f.TypeID, f.NotNull, colType, ct.Name, ct.Schema)
+				ct.Fields = append(ct.Fields, f)
+			}
+			in.result.Composites = append(in.result.Composites, ct)
+		}
+	}
+
+	sort.Slice(in.result.Composites, func(i, j int) bool {
+		if in.result.Composites[i].Name != in.result.Composites[j].Name {
+			return in.result.Composites[i].Name < in.result.Composites[j].Name
+		}
+		return in.result.Composites[i].OID < in.result.Composites[j].OID
+	})
+	return nil
+}
+
+// readCompositeFields reads the fields of composite types, in a single
+// query, and returns them grouped by type oid
+func (in *Introspector) readCompositeFields(oids []uint32) (map[uint32][]Field, error) {
+	q, err := in.db.Query(`select t.oid, a.attnum, a.attname, format_type(a.atttypid, NULL),`+
+		` a.attnotnull, a.attndims <> 0, a.atttypid`+
+		` from pg_attribute a, pg_type t`+
+		` where t.oid = any($1::int8[]::oid[]) and a.attrelid = t.typrelid`+
+		` and a.attnum > 0 and not a.attisdropped`+
+		` order by t.oid, a.attnum`, int64OIDs(oids))
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+	fields := map[uint32][]Field{}
+	for q.Next() {
+		var oid uint32
+		f := Field{
+			Visible: true,
+		}
+		err = q.Scan(&oid, &f.Position, &f.Name, &f.Type, &f.NotNull, &f.Array, &f.TypeID)
+		if err != nil {
+			return nil, err
+		}
+		fields[oid] = append(fields[oid], f)
+	}
+	return fields, q.Err()
+}
+
+// loadEnums loads the enum types that we've seen in a table
+func (in *Introspector) loadEnums() error {
+	oids := make([]uint32, 0, len(in.seenEnums))
+	for oid := range in.seenEnums {
+		oids = append(oids, oid)
+	}
+	sort.Slice(oids, func(i, j int) bool {
+		if in.seenEnums[oids[i]] != in.seenEnums[oids[j]] {
+			return in.seenEnums[oids[i]] < in.seenEnums[oids[j]]
+		}
+		return oids[i] < oids[j]
+	})
+	if len(oids) == 0 {
+		return nil
+	}
+
+	q, err := in.db.Query(`select enumtypid, enumlabel from pg_enum`+
+		` where enumtypid = any($1::int8[]::oid[])`+
+		` order by enumtypid, enumsortorder`, int64OIDs(oids))
+	if err != nil {
+		return err
+	}
+	defer q.Close()
+	labels := map[uint32][]string{}
+	for q.Next() {
+		var oid uint32
+		var label string
+		err = q.Scan(&oid, &label)
+		if err != nil {
+			return err
+		}
+		labels[oid] = append(labels[oid], label)
+	}
+	if q.Err() != nil {
+		return q.Err()
+	}
+
+	for _, oid := range oids {
+		in.result.Enums = append(in.result.Enums, Enum{
+			OID:    oid,
+			Name:   in.seenEnums[oid],
+			Schema: in.typeSchemas[oid],
+			Labels: append([]string{}, labels[oid]...),
+		})
+	}
+	return nil
+}
+
+// readIndexes finds all the unique indexes for all our tables
+func (in *Introspector) readIndexes() error {
+	indexes, err := in.readUniques()
+	if err != nil {
+		return err
+	}
+	for k, v := range in.result.Tables {
+		v.Indexes = uniques(v, indexes[v.OID])
+		for _, idx := range v.Indexes {
+			if idx.PrimaryKey {
+				v.Primary = idx
+				// All the columns of the primary key are used for update / upsert / delete
+				v.PrimaryFields = []Field{}
+				for _, col := range idx.Columns {
+					for _, field := range v.Fields {
+						if field.Name == col {
+							v.PrimaryFields = append(v.PrimaryFields, field)
+						}
+					}
+				}
+				// If there's a single column primary key with a default we let the
+				// database fill it in on insert
+				if len(idx.Columns) == 1 {
+					for _, field := range v.Fields {
+						if field.Name == idx.Columns[0] && field.HasDefault {
+							v.IDField = field
+						}
+					}
+				}
+			}
+			if in.config.GenerateUniqueQueries || (in.config.GeneratePKQueries && idx.PrimaryKey) {
+				// Generate a query based on this index
+				nameParts := []string{v.Alias, "by"}
+				paramParts := []string{}
+				for pidx, pname := range idx.Columns {
+					nameParts = append(nameParts, pname)
+					paramParts = append(paramParts, fmt.Sprintf("%s = $%d", maybequote1(pname), pidx+1))
+				}
+
+				in.addQuery(v.Schema, in.names.goname(strings.Join(nameParts, "_")),
+					fmt.Sprintf("select * from %s.%s where %s", maybequote1(v.Schema), maybequote1(v.Name), strings.Join(paramParts, " and ")))
+			}
+		}
+		in.result.Tables[k] = v
+	}
+	return nil
+}
+
+// foreignKey is a foreign key as read from pg_constraint
+type foreignKey struct {
+	name           string
+	conkey         []int16
+	foreignSchema  string
+	foreignTable   string
+	foreignColumns []string
+}
+
+// readFKs finds the foreign keys for all our tables. The tables they refer
+// to are read from the catalog, as they may not be ones we've included.
+func (in *Introspector) readFKs() error {
+	q, err := in.db.Query(`select c.conrelid, c.conname, c.conkey, n.nspname, f.relname,`+
+		` array(select a.attname from pg_attribute a, unnest(c.confkey) with ordinality k(attnum, i)`+
+		` where a.attrelid = c.confrelid and a.attnum = k.attnum order by k.i)::text[]`+
+		` from pg_constraint c, pg_class f, pg_namespace n`+
+		` where c.conrelid = any($1::int8[]::oid[]) and c.contype = 'f'`+
+		` and f.oid = c.confrelid and n.oid = f.relnamespace`+
+		` order by c.conname`, in.tableOIDs())
+	if err != nil {
+		return err
+	}
+	defer q.Close()
+
+	fks := map[uint32][]foreignKey{}
+	for q.Next() {
+		var conrelid uint32
+		fk := foreignKey{
+			conkey:         []int16{},
+			foreignColumns: []string{},
+		}
+		err = q.Scan(&conrelid, &fk.name, &fk.conkey, &fk.foreignSchema, &fk.foreignTable, &fk.foreignColumns)
+		if err != nil {
+			return err
+		}
+		fks[conrelid] = append(fks[conrelid], fk)
+	}
+	if q.Err() != nil {
+		return q.Err()
+	}
+
+	for k, table := range in.result.Tables {
+		for _, con := range fks[table.OID] {
+			fk := ForeignKey{
+				Name:           con.name,
+				Columns:        []string{},
+				ForeignSchema:  con.foreignSchema,
+				ForeignTable:   con.foreignTable,
+				ForeignColumns: con.foreignColumns,
+			}
+
+			if len(con.conkey) == 0 || len(con.conkey) != len(con.foreignColumns) {
+				// ??
+				continue
+			}
+			for _, col := range con.conkey {
+				if col < 1 || int(col) > len(table.Fields) {
+					return fmt.Errorf("Column %d of foreign key %s outside columns for %s", col, fk.Name, table.Name)
+				}
+				fk.Columns = append(fk.Columns, table.Fields[col-1].Name)
+			}
+
+			in.result.Tables[k].ForeignKeys = append(in.result.Tables[k].ForeignKeys, fk)
+			if in.config.GenerateFKQueries {
+				nameParts := []string{table.Alias, "by"}
+				paramParts := []string{}
+				for pidx, pname := range fk.Columns {
+					nameParts = append(nameParts, pname)
+					paramParts = append(paramParts, fmt.Sprintf("%s = $%d", maybequote1(pname), pidx+1))
+				}
+				in.addQuery(table.Schema, in.names.goname(strings.Join(nameParts, "_")),
+					fmt.Sprintf("select * from %s.%s where %s", maybequote1(table.Schema), maybequote1(table.Name), strings.Join(paramParts, " and ")))
+			}
+		}
+	}
+	return nil
+}
+
+// nameRelations chooses names for the methods and functions that load the
+// rows at either end of a foreign key. Method names avoid the fields and
+// other methods of the type they're on.
+func (in *Introspector) nameRelations() error {
+	used := make([]map[string]struct{}, len(in.result.Tables))
+	for k, t := range in.result.Tables {
+		used[k] = map[string]struct{}{}
+		for _, name := range []string{"Insert", "InsertDefaults", "Update", "UpdateChanged", "Upsert", "Delete"} {
+			used[k][name] = struct{}{}
+		}
+		for _, f := range t.Fields {
+			used[k][in.names.goname(f.Name)] = struct{}{}
+			used[k]["Set"+in.names.goname(f.Name)] = struct{}{}
+		}
+	}
+	choose := func(k int, candidates ...string) string {
+		for _, name := range candidates {
+			if _, ok := used[k][name]; !ok {
+				used[k][name] = struct{}{}
+				return name
+			}
+		}
+		for i := 2; ; i++ {
+			name := fmt.Sprintf("%s%d", candidates[len(candidates)-1], i)
+			if _, ok := used[k][name]; !ok {
+				used[k][name] = struct{}{}
+				return name
+			}
+		}
+	}
+
+	for k, t := range in.result.Tables {
+		for fkidx, fk := range t.ForeignKeys {
+			pk := -1
+			for i, parent := range in.result.Tables {
+				if parent.Schema == fk.ForeignSchema && parent.Name == fk.ForeignTable {
+					pk = i
+				}
+			}
+			if pk == -1 {
+				// It refers to a table we're not including
+				continue
+			}
+			parent := in.result.Tables[pk]
+			columns := columnFields(t, fk.Columns)
+			foreignColumns := columnFields(parent, fk.ForeignColumns)
+			if columns == nil || foreignColumns == nil {
+				// It uses columns we're ignoring
+				continue
+			}
+
+			parentType := in.names.goname(parent.Alias)
+			childType := in.names.goname(t.Alias)
+			byColumns := strings.TrimSuffix(strings.Join(fk.Columns, "_"), "_id")
+			fk.ParentMethod = choose(k, parentType, in.names.goname(byColumns))
+
+			parentOutput := in.config.Output(parent.Schema)
+			if parentOutput.Directory != in.config.Output(t.Schema).Directory {
+				// The parent is in another package, which can't refer back to
+				// this one, so there's only a method to load the parent
+				fk.ForeignPackage = parentOutput.Package
+				if len(columns) == 1 && columns[0].NotNull && foreignColumns[0].NotNull &&
+					columns[0].GoType == foreignColumns[0].GoType && mapKeyType(columns[0].GoType) {
+					fk.BatchFunction = "Load" + fk.ParentMethod + "For" + childType
+				}
+				in.result.Tables[k].ForeignKeys[fkidx] = fk
+				continue
+			}
+
+			ref := Reference{
+				Name:           fk.Name,
+				Schema:         t.Schema,
+				Table:          t.Name,
+				Columns:        fk.Columns,
+				ForeignColumns: fk.ForeignColumns,
+				ChildrenMethod: choose(pk, childType, in.names.goname(t.Alias+"_by_"+strings.Join(fk.Columns, "_"))),
+			}
+
+			// Rows can be loaded in batches when there's a single column we
+			// can use as a map key
+			if len(columns) == 1 && foreignColumns[0].NotNull && mapKeyType(foreignColumns[0].GoType) {
+				ref.BatchFunction = "Load" + ref.ChildrenMethod + "For" + parentType
+				if columns[0].NotNull && columns[0].GoType == foreignColumns[0].GoType {
+					fk.BatchFunction = "Load" + fk.ParentMethod + "For" + childType
+				}
+			}
+			in.result.Tables[k].ForeignKeys[fkidx] = fk
+			in.result.Tables[pk].References = append(in.result.Tables[pk].References, ref)
+		}
+	}
+	return nil
+}
+
+// checkImports makes sure generated packages don't import each other, as
+// Go doesn't allow import cycles. Packages that use each other's types
+// can't be fixed, but loaders for foreign keys that would create a cycle are
+// left out.
+func (in *Introspector) checkImports() error {
+	froms := make([]string, 0, len(in.imports))
+	for from := range in.imports {
+		froms = append(froms, from)
+	}
+	sort.Strings(froms)
+	for _, from := range froms {
+		for to := range in.imports[from] {
+			if in.reaches(to, from) {
+				return fmt.Errorf("code generated in %s and %s uses types from each other, so they need to be in the same package", from, to)
+			}
+		}
+	}
+
+	for k, t := range in.result.Tables {
+		from := in.config.Output(t.Schema).Directory
+		for fkidx, fk := range t.ForeignKeys {
+			if fk.ForeignPackage == "" || fk.ParentMethod == "" {
+				continue
+			}
+			to := in.config.Output(fk.ForeignSchema).Directory
+			if in.reaches(to, from) {
+				in.Logf("Not generating %s.%s for foreign key %s, as %s already uses %s\n", in.names.goname(t.Alias), fk.ParentMethod, fk.Name, to, from)
+				fk.ParentMethod = ""
+				fk.BatchFunction = ""
+				in.result.Tables[k].ForeignKeys[fkidx] = fk
+				continue
+			}
+			if in.imports[from] == nil {
+				in.imports[from] = map[string]bool{}
+			}
+			in.imports[from][to] = true
+		}
+	}
+	return nil
+}
+
+// reaches checks whether code generated in directory from uses code in
+// directory to, directly or indirectly
+func (in *Introspector) reaches(from string, to string) bool {
+	seen := map[string]bool{}
+	pending := []string{from}
+	for len(pending) > 0 {
+		dir := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if dir == to {
+			return true
+		}
+		if seen[dir] {
+			continue
+		}
+		seen[dir] = true
+		for next := range in.imports[dir] {
+			pending = append(pending, next)
+		}
+	}
+	return false
+}
+
+// columnFields finds the visible fields of a table with the given names,
+// returning nil if any of them aren't there
+func columnFields(t Table, names []string) []Field {
+	fields := []Field{}
+	for _, name := range names {
+		found := false
+		for _, f := range t.Fields {
+			if f.Name == name && f.Visible {
+				fields = append(fields, f)
+				found = true
+			}
+		}
+		if !found {
+			return nil
+		}
+	}
+	return fields
+}
+
+// mapKeys are the Go types that batch loaders can use as map keys. Values
+// that are equal in the database need to be equal in Go, which rules out
+// types such as net.IP that can't be map keys, and those such as
+// time.Time or pgtype.Numeric that carry pointers.
+var mapKeys = map[string]bool{
+	"bool": true, "string": true, "byte": true, "rune": true,
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true,
+	"float32": true, "float64": true,
+	"sql.NullBool": true, "sql.NullByte": true, "sql.NullString": true, "sql.NullFloat64": true,
+	"sql.NullInt16": true, "sql.NullInt32": true, "sql.NullInt64": true,
+	"pgtype.Bool": true, "pgtype.Text": true, "pgtype.Float4": true, "pgtype.Float8": true,
+	"pgtype.Int2": true, "pgtype.Int4": true, "pgtype.Int8": true, "pgtype.UUID": true,
+	"uuid.UUID": true, "uuid.NullUUID": true,
+}
+
+// mapKeyType checks whether a Go type can be used as a map key by batch
+// loaders, as far as we can tell from its name
+func mapKeyType(goType string) bool {
+	if strings.HasPrefix(goType, "sql.Null[") && strings.HasSuffix(goType, "]") {
+		return mapKeys[strings.TrimSuffix(strings.TrimPrefix(goType, "sql.Null["), "]")]
+	}
+	return mapKeys[goType]
+}
+
+// uniqueIndex is a unique index as read from pg_index
+type uniqueIndex struct {
+	name       string
+	primaryKey bool
+	posns      []uint16
+}
+
+// readUniques reads the unique indexes of all tables, grouped by table oid
+func (in *Introspector) readUniques() (map[uint32][]uniqueIndex, error) {
+	q, err := in.db.Query(`select i.indrelid, i.indisprimary, i.indkey::int2[], c.relname`+
+		` from pg_index i, pg_class c`+
+		` where i.indrelid = any($1::int8[]::oid[])`+
+		` and i.indisunique`+
+		` and i.indexrelid = c.oid`+
+		` order by c.relname`, in.tableOIDs())
+
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+
+	indexes := map[uint32][]uniqueIndex{}
+	for q.Next() {
+		var indrelid uint32
+		idx := uniqueIndex{
+			posns: []uint16{},
+		}
+		err = q.Scan(&indrelid, &idx.primaryKey, &idx.posns, &idx.name)
+		if err != nil {
+			return nil, err
+		}
+		indexes[indrelid] = append(indexes[indrelid], idx)
+	}
+	return indexes, q.Err()
+}
+
+// uniques finds the usable unique indexes for a table
+func uniques(t Table, indexes []uniqueIndex) []Unique {
+	uniques := []Unique{}
+OUTER:
+	for _, idx := range indexes {
+		u := Unique{
+			Name:       idx.name,
+			PrimaryKey: idx.primaryKey,
+		}
+
+		if len(idx.posns) == 0 {
+			continue
+		}
+		for _, pos := range idx.posns {
+			if pos == 0 {
+				// Functional index
+				continue OUTER
+			}
+			// Postgresql is 1-based, we're 0-based
+			if !t.Fields[pos-1].Visible {
+				// Index on a column we're ignoring
+				continue OUTER
+			}
+			u.Columns = append(u.Columns, t.Fields[pos-1].Name)
+		}
+		uniques = append(uniques, u)
+	}
+	return uniques
+}
+
+// namedQuery is a query we're going to generate code for
+type namedQuery struct {
+	name  string
+	query string
+	// directory is the output directory of the table a generated query is
+	// for, or empty for queries from the configuration file
+	directory string
+}
+
+// Read the user-provided SQL queries from the configuration file
+func (in *Introspector) readQueries() error {
+	for _, name := range sortedKeys(in.config.Queries) {
+		in.queries = append(in.queries, namedQuery{name: name, query: in.config.Queries[name]})
+	}
+	return nil
+}
+
+// add a generated query for a table in schema, renaming it if needed to
+// avoid clashes with other queries in the same package
+func (in *Introspector) addQuery(schema string, name string, query string) {
+	directory := in.config.Output(schema).Directory
+	for {
+		clash := false
+		for _, q := range in.queries {
+			if q.name == name && (q.directory == "" || q.directory == directory) {
+				clash = true
+			}
+		}
+		if !clash {
+			break
+		}
+		name = name + "_"
+	}
+	in.queries = append(in.queries, namedQuery{name: name, query: query, directory: directory})
+}
+
+func (in *Introspector) generateQueries() error {
+	// Work through queries in a fixed order, so that any name collisions
+	// are resolved the same way every time
+	queries := append([]namedQuery{}, in.queries...)
+	sort.SliceStable(queries, func(i, j int) bool {
+		if queries[i].name != queries[j].name {
+			return queries[i].name < queries[j].name
+		}
+		return queries[i].directory < queries[j].directory
+	})
+	for _, q := range queries {
+		err := in.readQuery(q.name, q.query, false)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (in *Introspector) readQuery(name string, query string, single bool) error {
+	starre := regexp.MustCompile(`(?is)^\s*select\s+\*\s+(.*)`)
+	realquery := query
+
+	// Prepare the query, so we can get metadata about parameters and results.
+	// It's left unnamed, so the connection isn't left holding it.
+	prepared, err := in.db.Prepare("", query)
+	if err != nil {
+		return fmt.Errorf("while preparing query %s: %s", name, err)
+	}
+
+	// A statement that returns nothing, such as an insert, update or
+	// delete without a returning clause
+	exec := len(prepared.FieldDescriptions) == 0
+
+	// Does every column come from the same table?
+	tableoid := uint32(0)
+	if !exec {
+		tableoid = uint32(prepared.FieldDescriptions[0].Table)
+	}
+	for _, fd := range prepared.FieldDescriptions {
+		if uint32(fd.Table) != tableoid {
+			tableoid = 0
+			break
+		}
+	}
+
+	// Find the table to generate the query along with, preferring one that
+	// the query returns columns from
+	tableidx := -1
+	for _, fd := range prepared.FieldDescriptions {
+		tableidx = in.tableIndex(uint32(fd.Table))
+		if tableidx != -1 {
+			break
+		}
+	}
+	if tableidx == -1 {
+		tableidx = in.findQueryTable(query)
+	}
+	if tableidx == -1 {
+		return fmt.Errorf("query %s doesn't use any table that's included - not supported", name)
+	}
+	table := in.result.Tables[tableidx]
+
+	if tableoid == table.OID {
+		cols := []string{}
+		for _, f := range table.Fields {
+			if !f.Visible {
+				continue
+			}
+			cols = append(cols, maybequote1(f.Name))
+		}
+		if matches := starre.FindStringSubmatch(query); matches != nil {
+			// it's a select * from a single table - rewrite to use concrete columns
+			realquery = `select ` + strings.Join(cols, ", ") + " " + matches[1]
+		} else if matches := returningStarRe.FindStringSubmatch(query); matches != nil {
+			// returning * - rewrite to use concrete columns
+			realquery = matches[1] + strings.Join(cols, ", ") + matches[2]
+		}
+	}
+	if realquery != query {
+		prepared, err = in.db.Prepare("", realquery)
+		if err != nil {
+			return fmt.Errorf("while preparing query for *-expanded %s: %s", name, err)
+		}
+	}
+
+	// If the query returns exactly the columns of the table we can use the
+	// struct we already have for it, otherwise it needs one of its own.
+	// Rows read through an outer join may be all nulls, so they always
+	// need one of their own.
+	outer := outerJoins(query)
+	resultType := ""
+	returnedFields := []Field{}
+	switch {
+	case exec:
+		// No rows, so nothing to fit them into
+	case tableoid == table.OID && returnsTableRow(table, prepared.FieldDescriptions) && !outer.nullable(table):
+		for _, fd := range prepared.FieldDescriptions {
+			returnedFields = append(returnedFields, table.Fields[fd.AttributeNumber-1])
+		}
+	default:
+		resultType = name + "Row"
+		// Tables in the same package get their names first, so that a
+		// clash is reported rather than one of them being renumbered
+		dir := in.config.Output(table.Schema).Directory
+		for _, t := range in.result.Tables {
+			if in.config.Output(t.Schema).Directory == dir && in.names.goname(t.Alias) == resultType {
+				return fmt.Errorf("query %s returns rows as %s, which is already the name of the struct for table %s.%s - rename the query, or rename the table in its Table section", name, resultType, t.Schema, t.Name)
+			}
+		}
+		if clash := in.typeClash(dir, resultType); clash != "" {
+			return fmt.Errorf("query %s returns rows as %s, which is already the name of the Go type for %s - rename the query", name, resultType, clash)
+		}
+		if !in.names.reserve(resultType) {
+			return fmt.Errorf("query %s returns rows as %s, but another query's rows already have that name - rename the query", name, resultType)
+		}
+		returnedFields, err = in.resultFields(name, table.Schema, prepared.FieldDescriptions, outer)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Handle the $1, $2, $3 ... parameters
+	parameterFields := []Field{}
+	eqParameters := []string{}
+	for i, paramoid := range prepared.ParameterOIDs {
+		paramField := Field{
+			Position: i + 1,
+			TypeID:   uint32(paramoid),
+		}
+		findNameRe := regexp.MustCompile(fmt.Sprintf(`\$%d\s*/\*\s*([^*]*[^ *])\s*\*/`, i+1))
+		// Look for  annotations of the form /* name */ or /* name gotype */
+		matches := findNameRe.FindStringSubmatch(query)
+		if matches != nil {
+			parts := regexp.MustCompile(`\s+`).Split(matches[1], -1)
+			if len(parts) > 2 {
+				return fmt.Errorf("query %s - couldn't understand type annotation '%s'", name, matches[1])
+			}
+			if len(parts) > 1 {
+				paramField.GoType = parts[1]
+			}
+			if len(parts) > 0 {
+				paramField.Name = parts[0]
+			}
+		}
+
+		// Crude check for where constraints of the form <column_name>=$<digit>
+		findEqualRe := regexp.MustCompile(fmt.Sprintf(`(\S+)\s*=\s*\$%d`, i+1))
+		matches = findEqualRe.FindStringSubmatch(query)
+		if matches != nil {
+			eqParameters = append(eqParameters, matches[1])
+		}
+		if paramField.Name == "" {
+			column := ""
+			if matches != nil {
+				column = matches[1]
+			}
+			paramField.Name = in.parameterName(column, i+1)
+		}
+
+		if paramField.GoType == "" {
+			// OK, lets try and guess based on the paramoid
+			paramField.GoType = in.goType(uint32(paramoid), true, fmt.Sprintf("$%d", i+1), name, table.Schema)
+		}
+		parameterFields = append(parameterFields, paramField)
+	}
+
+	// If query ends in "limit 1", inserts a single row of values or includes
+	// /* singlerow */ make it return a single row
+	limitre := regexp.MustCompile(`(?i)limit\s+1\s*;?\s*$`)
+
+	if limitre.MatchString(query) || strings.Contains(query, "/* singlerow */") {
+		single = true
+	}
+	if insertValuesRe.MatchString(query) && !multipleValuesRe.MatchString(query) {
+		single = true
+	}
+
+	// Deep nesting, but the number of indexes and parameters will be small
+	// https://accidentallyquadratic.tumblr.com
+	if !single && len(eqParameters) > 0 {
+		// There's at least one parameter of the form column = parameter
+		for _, index := range table.Indexes {
+			columnMatches := 0
+			for _, indexCol := range index.Columns {
+				for _, paramCol := range eqParameters {
+					if paramCol == indexCol {
+						columnMatches++
+					}
+				}
+			}
+			if columnMatches == len(index.Columns) {
+				// our parameters are a superset of the columns of a unique index, so we'll return one row
+				single = true
+				break
+			}
+		}
+	}
+
+	// Provide an escape hatch for when we want multiple rows
+	if strings.Contains(query, "/* multirow */") {
+		single = false
+	}
+
+	table.Queries = append(table.Queries, Query{
+		Name:          name,
+		Query:         realquery,
+		OriginalQuery: query,
+		Fields:        returnedFields,
+		Parameters:    parameterFields,
+		SingleRow:     single,
+		ResultType:    resultType,
+		Exec:          exec,
+	})
+	in.result.Tables[tableidx] = table
+
+	return nil
+}
+
+// typeClash finds the enum, composite type or domain already named goname
+// in code generated in directory dir, returning a description of it or ""
+// if there isn't one. Those that haven't been named yet will be given
+// another name if goname is reserved.
+func (in *Introspector) typeClash(dir string, goname string) string {
+	type namedType struct {
+		kind   string
+		schema string
+		name   string
+	}
+	types := []namedType{}
+	for oid, name := range in.allEnums {
+		types = append(types, namedType{"enum", in.typeSchemas[oid], name})
+	}
+	for oid, name := range in.allComposites {
+		types = append(types, namedType{"composite type", in.typeSchemas[oid], name})
+	}
+	for _, d := range in.allDomains {
+		types = append(types, namedType{"domain", d.Schema, d.Name})
+	}
+	sort.Slice(types, func(i, j int) bool {
+		if types[i].schema != types[j].schema {
+			return types[i].schema < types[j].schema
+		}
+		return types[i].name < types[j].name
+	})
+	for _, t := range types {
+		if in.config.Output(t.schema).Directory != dir {
+			continue
+		}
+		if r, ok := in.names.named(t.name); ok && (r == goname || "Null"+r == goname) {
+			return fmt.Sprintf("%s %s.%s", t.kind, t.schema, t.name)
+		}
+	}
+	return ""
+}
+
+// parameterName chooses the name of the parameter at position that a query
+// compares with column, which may be qualified with a table or alias, or
+// quoted. Parameters that can't be named after a column are called p1, p2
+// and so on.
+func (in *Introspector) parameterName(column string, position int) string {
+	if i := strings.LastIndex(column, "."); i != -1 {
+		column = column[i+1:]
+	}
+	column = strings.Trim(column, `"`)
+	if strings.Contains(column, "_") {
+		column = in.names.goname(column)
+	}
+	if !token.IsIdentifier(column) {
+		return fmt.Sprintf("p%d", position)
+	}
+	return column
+}
+
+// tableIndex finds the included table with the given oid, returning -1
+// if there isn't one
+func (in *Introspector) tableIndex(oid uint32) int {
+	if oid == 0 {
+		return -1
+	}
+	for i, t := range in.result.Tables {
+		if t.OID == oid {
+			return i
+		}
+	}
+	return -1
+}
+
+var fromTableRe = regexp.MustCompile(`(?is)\b(?:from|into|update)\s+(?:only\s+)?([^\s,();]+)`)
+var returningStarRe = regexp.MustCompile(`(?is)^(.*\sreturning\s+)\*(\s*;?\s*)$`)
+var insertValuesRe = regexp.MustCompile(`(?is)^\s*insert\s.*\svalues\s*\(`)
+var multipleValuesRe = regexp.MustCompile(`(?is)\svalues\s*\(.*\)\s*,\s*\(`)
+
+// findQueryTable makes a crude guess at the table a query uses, for
+// queries that don't return any of its columns
+func (in *Introspector) findQueryTable(query string) int {
+	matches := fromTableRe.FindStringSubmatch(query)
+	if matches == nil {
+		return -1
+	}
+	name := strings.Replace(matches[1], `"`, "", -1)
+	found := -1
+	for i, t := range in.result.Tables {
+		if name == t.Schema+"."+t.Name {
+			return i
+		}
+		// An unqualified name is most likely found through the default
+		// search_path, so prefer a table in public
+		if name == t.Name && (found == -1 || t.Schema == "public") {
+			found = i
+		}
+	}
+	return found
+}
+
+var outerJoinRe = regexp.MustCompile(`(?is)\b(left|right|full)\s+(?:outer\s+)?join\s+(?:only\s+)?(\(|[^\s,();]+)`)
+
+// outerJoin is a crude guess at which tables a query reads through the
+// nullable side of an outer join
+type outerJoin struct {
+	// all is set when we can't tell, such as for a right or full join, so
+	// every table may be on the nullable side
+	all    bool
+	tables map[string]bool
+}
+
+// outerJoins finds the tables on the nullable side of the outer joins in
+// a query. Only the table named in a left join is, anything more complex
+// is treated as making every table nullable.
+func outerJoins(query string) outerJoin {
+	o := outerJoin{tables: map[string]bool{}}
+	for _, m := range outerJoinRe.FindAllStringSubmatch(query, -1) {
+		if !strings.EqualFold(m[1], "left") || m[2] == "(" || strings.EqualFold(m[2], "lateral") {
+			o.all = true
+			continue
+		}
+		o.tables[strings.Replace(m[2], `"`, "", -1)] = true
+	}
+	return o
+}
+
+// nullable checks whether a table's columns may be null in a query's
+// results, whatever their constraints
+func (o outerJoin) nullable(t Table) bool {
+	return o.all || o.tables[t.Name] || o.tables[t.Schema+"."+t.Name]
+}
+
+// returnsTableRow checks whether a query returns exactly the visible columns
+// of a table, in order
+func returnsTableRow(t Table, fds []pgx.FieldDescription) bool {
+	i := 0
+	for _, f := range t.Fields {
+		if !f.Visible {
+			continue
+		}
+		if i >= len(fds) || int(fds[i].AttributeNumber) != f.Position {
+			return false
+		}
+		i++
+	}
+	return i == len(fds)
+}
+
+// resultFields builds the fields of the struct generated for a query that
+// doesn't return rows of a single table, in code generated for schema
+func (in *Introspector) resultFields(name string, schema string, fds []pgx.FieldDescription, outer outerJoin) ([]Field, error) {
+	fields := []Field{}
+	seen := map[string]struct{}{}
+	for i, fd := range fds {
+		f := Field{
+			Name:     fd.Name,
+			Position: i + 1,
+			Type:     fd.DataTypeName,
+			TypeID:   uint32(fd.DataType),
+		}
+
+		// Columns that come straight from an included table keep their
+		// nullability and type, including any ColumnType override
+		tableidx := in.tableIndex(uint32(fd.Table))
+		if tableidx != -1 && int(fd.AttributeNumber) <= len(in.result.Tables[tableidx].Fields) {
+			source := in.result.Tables[tableidx]
+			column := source.Fields[fd.AttributeNumber-1]
+			f.Type = column.Type
+			f.NotNull = column.NotNull
+			f.Array = column.Array
+			f.GoType = column.GoType
+			if outer.nullable(source) {
+				// The column is null when the outer join doesn't match
+				f.NotNull = false
+			}
+			if f.NotNull != column.NotNull || in.config.Output(source.Schema).Directory != in.config.Output(schema).Directory {
+				// The column needs a nullable type, or its type may be
+				// generated in its table's package
+				f.GoType = ""
+				if gotype, ok := in.tableConfig(source).ColumnType[column.Name]; ok {
+					f.GoType = gotype
+				}
+			}
+			if _, dup := seen[f.Name]; dup {
+				f.Name = in.result.Tables[tableidx].Name + "_" + f.Name
+			}
+		}
+
+		if f.Name == "?column?" {
+			return nil, fmt.Errorf("query %s - column %d needs a name, add one with 'as'", name, i+1)
+		}
+		if _, dup := seen[f.Name]; dup {
+			return nil, fmt.Errorf("query %s - more than one column is called %s, rename one with 'as'", name, f.Name)
+		}
+		seen[f.Name] = struct{}{}
+
+		if f.GoType == "" {
+			typename := f.Type
+			if typename == "" {
+				typename = strconv.Itoa(int(fd.DataType))
+			}
+			f.GoType = in.goType(f.TypeID, f.NotNull, typename, name, schema)
+		}
+		f.Visible = true
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// fixQueryParameters renames parameters so as not to clash with
+// variables used in the generated code.
+func (in *Introspector) fixQueryParameters() {
+	rn := in.config.ReservedNames
+	if len(rn) == 0 {
+		rn = []string{"q", "query", "row", "result", "db", "err", "ctx", "tag"}
+	}
+	exclude := map[string]struct{}{}
+	for _, name := range rn {
+		exclude[name] = struct{}{}
+	}
+
+	for tableidx, table := range in.result.Tables {
+		for queryidx, query := range table.Queries {
+			for paramidx, param := range query.Parameters {
+				_, ok := exclude[param.Name]
+				if ok {
+					// Clashes
+					param.Name = param.Name + "_"
+					query.Parameters[paramidx] = param
+				}
+			}
+			table.Queries[queryidx] = query
+		}
+		in.result.Tables[tableidx] = table
+	}
+}
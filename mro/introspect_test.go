@@ -0,0 +1,143 @@
+package mro
+
+import (
+	"testing"
+)
+
+func TestOuterJoins(t *testing.T) {
+	account := Table{Schema: "public", Name: "account"}
+	orders := Table{Schema: "public", Name: "orders"}
+	tests := []struct {
+		query    string
+		nullable []Table
+		notNull  []Table
+	}{
+		{
+			query:   "select a.name, o.note from account a join orders o on o.account_id = a.id",
+			notNull: []Table{account, orders},
+		},
+		{
+			query:    "select a.name, o.note from account a left join orders o on o.account_id = a.id",
+			nullable: []Table{orders},
+			notNull:  []Table{account},
+		},
+		{
+			query:    `select a.name, o.note from account a LEFT OUTER JOIN public."orders" o on o.account_id = a.id`,
+			nullable: []Table{orders},
+			notNull:  []Table{account},
+		},
+		{
+			query:    "select a.name, o.note from orders o right join account a on o.account_id = a.id",
+			nullable: []Table{account, orders},
+		},
+		{
+			query:    "select a.name, o.note from account a full join orders o on o.account_id = a.id",
+			nullable: []Table{account, orders},
+		},
+		{
+			query:    "select a.name, o.note from account a left join (select * from orders) o on o.account_id = a.id",
+			nullable: []Table{account, orders},
+		},
+	}
+	for _, test := range tests {
+		o := outerJoins(test.query)
+		for _, table := range test.nullable {
+			if !o.nullable(table) {
+				t.Errorf("%s isn't nullable in %s", table.Name, test.query)
+			}
+		}
+		for _, table := range test.notNull {
+			if o.nullable(table) {
+				t.Errorf("%s is nullable in %s", table.Name, test.query)
+			}
+		}
+	}
+}
+
+func TestReserveIgnoresFieldNames(t *testing.T) {
+	in := NewIntrospector(Config{}, nil)
+	in.names = newNamer(nil)
+	in.allEnums = map[uint32]string{100: "mood"}
+	in.typeSchemas = map[uint32]string{100: "public"}
+
+	// A column in another table that happens to have the same name as a
+	// query's result type doesn't clash with it
+	column := in.names.goname("counts_row")
+	if in.typeClash(".", column) != "" || !in.names.reserve(column) {
+		t.Errorf("%s clashes with a column name", column)
+	}
+	if in.names.reserve(column) {
+		t.Errorf("%s was reserved twice", column)
+	}
+	if in.names.goname("counts_row_") == column {
+		t.Errorf("%s was given to a database name after it was reserved", column)
+	}
+
+	// Types do
+	enum := in.names.goname("mood")
+	if in.typeClash(".", enum) == "" || in.typeClash(".", "Null"+enum) == "" {
+		t.Errorf("%s doesn't clash with the enum", enum)
+	}
+}
+
+func TestForeignKeyToExcludedTable(t *testing.T) {
+	c := styleConfig(t, "pgx")
+	result := testResult(t, c)
+	for _, table := range result.Tables {
+		for _, fk := range table.ForeignKeys {
+			if fk.ForeignSchema == "audit" && (fk.ParentMethod != "" || fk.BatchFunction != "") {
+				t.Errorf("%s.%s has a loader for %s, which refers to a table that isn't included", table.Schema, table.Name, fk.Name)
+			}
+		}
+		for _, ref := range table.References {
+			if ref.Name == "orders_clerk_id_fkey" {
+				t.Errorf("%s.%s refers to orders, through a foreign key to a table that isn't included", table.Schema, table.Name)
+			}
+		}
+	}
+	for _, fk := range result.Tables[1].ForeignKeys {
+		if fk.Name == "orders_account_id_fkey" && fk.ParentMethod == "" {
+			t.Errorf("no loader for %s", fk.Name)
+		}
+	}
+}
+
+func TestParameterName(t *testing.T) {
+	in := NewIntrospector(Config{}, nil)
+	in.names = newNamer(nil)
+	tests := map[string]string{
+		"":              "p1",
+		"id":            "id",
+		"account_id":    "AccountID",
+		"o.id":          "id",
+		"o.account_id":  "AccountID",
+		`"Id"`:          "Id",
+		`o."Id"`:        "Id",
+		`public.o."Id"`: "Id",
+		"(id":           "p1",
+		`"two words"`:   "p1",
+		"type":          "p1",
+	}
+	for column, want := range tests {
+		got := in.parameterName(column, 1)
+		if got != want {
+			t.Errorf("parameter compared with %s is called %s, expected %s", column, got, want)
+		}
+	}
+}
+
+func TestMapKeyType(t *testing.T) {
+	keys := []string{"int64", "string", "sql.NullInt64", "pgtype.Int8", "uuid.UUID", "sql.Null[int64]"}
+	for _, goType := range keys {
+		if !mapKeyType(goType) {
+			t.Errorf("%s can't be used as a map key", goType)
+		}
+	}
+	notKeys := []string{"net.IP", "[]byte", "[]int64", "map[string]string", "time.Time", "pgtype.Numeric",
+		"*net.IP", "json.RawMessage", "sql.Null[[]byte]", "?unknown?", "Mood"}
+	for _, goType := range notKeys {
+		if mapKeyType(goType) {
+			t.Errorf("%s can be used as a map key", goType)
+		}
+	}
+}
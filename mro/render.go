@@ -0,0 +1,1090 @@
+package mro
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/kenshaw/snaker"
+)
+
+// Renderer generates code from a Result, using the templates and filenames
+// given in a configuration
+type Renderer struct {
+	config Config
+	result Result
+	names  *namer
+}
+
+// NewRenderer creates a Renderer for result. Go names are chosen the same
+// way as they were when result was introspected.
+func NewRenderer(config Config, result Result) *Renderer {
+	return &Renderer{
+		config: config,
+		result: result,
+		names:  newNamer(result.GoNames),
+	}
+}
+
+// Funcs returns the functions available to templates
+func (r *Renderer) Funcs() template.FuncMap {
+	return template.FuncMap{
+		"join":           strings.Join,
+		"goname":         r.names.goname,
+		"upper":          strings.ToUpper,
+		"lower":          strings.ToLower,
+		"title":          strings.Title,
+		"camel":          snaker.SnakeToCamel,
+		"snake":          snaker.CamelToSnake,
+		"inc":            func(i int) int { return i + 1 },
+		"names":          fieldNames,
+		"excludefield":   excludeField,
+		"excludefields":  excludeFields,
+		"writable":       writable,
+		"identityalways": identityAlways,
+		"hasdefault":     hasDefault,
+		"fieldindex":     fieldIndex,
+		"table":          r.table,
+		"columns":        columnFields,
+		"bindvars":       bindvars,
+		"bindvarsfrom":   bindvarsFrom,
+		"assign":         assign,
+		"gonames":        r.names.gonames,
+		"maybequote":     maybequote,
+		"prefix":         prefix,
+		"wrapname":       r.names.wrapname,
+		"qualify":        qualify,
+	}
+}
+
+// qualify prefixes name with a package name, if there is one
+func qualify(pkg string, name string) string {
+	if pkg == "" {
+		return name
+	}
+	return pkg + "." + name
+}
+
+func (n *namer) wrapname(fields []Field, pfx, sfx string) []string {
+	//fmt.Fprintf(os.Stderr, "%#v %#v %#v", name, pfx, sfx)
+	var ret []string
+	for _, field := range fields {
+		ret = append(ret, pfx+n.goname(field.Name))
+	}
+	return ret
+}
+
+func prefix(in []string, pfx string) []string {
+	s := make([]string, len(in))
+	for k, v := range in {
+		s[k] = pfx + v
+	}
+	return s
+}
+
+var badFieldNameRE = regexp.MustCompile("[^a-z_]")
+
+func maybequote1(s string) string {
+	if badFieldNameRE.MatchString(s) {
+		return `"` + strings.Replace(s, `"`, `""`, -1) + `"`
+	}
+	return s
+}
+
+func maybequote(in interface{}) (interface{}, error) {
+	f := []string{}
+	switch x := in.(type) {
+	case string:
+		return maybequote1(x), nil
+	case []string:
+		f = x
+	case []Field:
+		f = fieldNames(x)
+	default:
+		return nil, fmt.Errorf("maybequote can't take a %T", in)
+	}
+
+	s := make([]string, len(f))
+	for k, v := range f {
+		s[k] = maybequote1(v)
+	}
+	return s, nil
+}
+
+func bindvars(f []Field) []string {
+	s := make([]string, len(f))
+	for i := 0; i < len(f); i++ {
+		s[i] = fmt.Sprintf("$%d", i+1)
+	}
+	return s
+}
+
+// bindvarsFrom is like bindvars, but numbers parameters from start
+func bindvarsFrom(f []Field, start int) []string {
+	s := make([]string, len(f))
+	for i := 0; i < len(f); i++ {
+		s[i] = fmt.Sprintf("$%d", i+start)
+	}
+	return s
+}
+
+// assign pairs up fields with values, for "column = value" lists
+func assign(f []Field, values []string) []string {
+	s := make([]string, len(f))
+	for k, v := range f {
+		s[k] = maybequote1(v.Name) + " = " + values[k]
+	}
+	return s
+}
+
+func fieldNames(f []Field) []string {
+	s := make([]string, len(f))
+	for k, v := range f {
+		s[k] = v.Name
+	}
+	return s
+}
+
+func (n *namer) gonames(f []Field, prefix string) []string {
+	s := make([]string, len(f))
+	for k, v := range f {
+		s[k] = prefix + n.goname(v.Name)
+	}
+	return s
+}
+
+func fieldContains(f []Field, needle Field) bool {
+	for _, v := range f {
+		if needle.Name == v.Name {
+			return true
+		}
+	}
+	return false
+}
+
+func excludeFields(f []Field, x []Field) []Field {
+	r := make([]Field, 0, len(f))
+	for _, v := range f {
+		if !fieldContains(x, v) {
+			r = append(r, v)
+		}
+	}
+	return r
+}
+
+// writable returns the fields that can be written to, leaving out generated
+// and identity always columns. Identity always columns in keep are kept, as
+// they can be written with OVERRIDING SYSTEM VALUE.
+func writable(f []Field, keep ...[]Field) []Field {
+	r := make([]Field, 0, len(f))
+	for _, v := range f {
+		if v.Generated {
+			continue
+		}
+		if v.IdentityAlways {
+			kept := false
+			for _, k := range keep {
+				if fieldContains(k, v) {
+					kept = true
+				}
+			}
+			if !kept {
+				continue
+			}
+		}
+		r = append(r, v)
+	}
+	return r
+}
+
+// fieldIndex returns the position of needle in f, or -1 if it isn't there
+func fieldIndex(f []Field, needle Field) int {
+	for k, v := range f {
+		if needle.Name == v.Name {
+			return k
+		}
+	}
+	return -1
+}
+
+// hasDefault returns the fields that have a default
+func hasDefault(f []Field) []Field {
+	r := make([]Field, 0, len(f))
+	for _, v := range f {
+		if v.HasDefault {
+			r = append(r, v)
+		}
+	}
+	return r
+}
+
+// identityAlways checks whether any of the fields are identity always
+// columns
+func identityAlways(f []Field) bool {
+	for _, v := range f {
+		if v.IdentityAlways {
+			return true
+		}
+	}
+	return false
+}
+
+func excludeField(f []Field, x Field) []Field {
+	r := make([]Field, 0, len(f))
+	for _, v := range f {
+		if x.Name != v.Name {
+			r = append(r, v)
+		}
+	}
+	return r
+}
+
+// table finds the table called name in schema
+func (r *Renderer) table(schema string, name string) (Table, error) {
+	for _, t := range r.result.Tables {
+		if t.Schema == schema && t.Name == name {
+			return t, nil
+		}
+	}
+	return Table{}, fmt.Errorf("no table called %s.%s", schema, name)
+}
+
+// GeneratedHeader is the first line of every file mro generates. Files
+// without it are never deleted or overwritten.
+const GeneratedHeader = "// Code generated by mro. DO NOT EDIT."
+
+// Output holds rendered files in memory, in the order they were
+// first written to
+type Output struct {
+	Names   []string
+	Content map[string]*bytes.Buffer
+}
+
+func newOutput() *Output {
+	return &Output{
+		Content: map[string]*bytes.Buffer{},
+	}
+}
+
+// writer returns somewhere to append content for filename
+func (o *Output) writer(filename string) io.Writer {
+	b, ok := o.Content[filename]
+	if !ok {
+		b = &bytes.Buffer{}
+		b.WriteString(GeneratedHeader + "\n\n")
+		o.Content[filename] = b
+		o.Names = append(o.Names, filename)
+	}
+	return b
+}
+
+// format formats the rendered Go files and fixes their imports, like
+// goimports, which also makes sure that they're valid Go before anything is
+// written. Packages that aren't imported are looked up in paths, rather
+// than in GOPATH or the module cache. Files are formatted in parallel, on
+// up to workers goroutines.
+func (o *Output) format(workers int, paths map[string]string) error {
+	formatted := make([][]byte, len(o.Names))
+	errs := make([]error, len(o.Names))
+	parallel(workers, len(o.Names), func(i int) {
+		filename := o.Names[i]
+		if !strings.HasSuffix(filename, ".go") {
+			return
+		}
+		formatted[i], errs[i] = fixImports(filename, o.Content[filename].Bytes(), paths)
+	})
+	for i, filename := range o.Names {
+		if errs[i] != nil {
+			return fmt.Errorf("generated code for %s isn't valid Go: %s", filename, errs[i])
+		}
+		if formatted[i] != nil {
+			o.Content[filename] = bytes.NewBuffer(formatted[i])
+		}
+	}
+	return nil
+}
+
+// parallel calls f with each of 0 to n-1, on up to workers goroutines
+func parallel(workers int, n int, f func(i int)) {
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				f(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+}
+
+// job renders a single enum, composite type, domain or table
+type job struct {
+	// kind is what's being rendered, such as "tables", for error messages
+	kind     string
+	name     string
+	filename string
+	tpl      *template.Template
+	render   func(r *Renderer, tpl *template.Template, w io.Writer) error
+}
+
+// Render renders all the enums, composite types, domains and tables into
+// memory, and formats them. They're rendered in parallel, but the output
+// is the same as if they'd been rendered one at a time.
+func (r *Renderer) Render() (*Output, error) {
+	jobs := []job{}
+	if r.config.EnumFilename != "" {
+		err := r.renderEnums(&jobs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render enums: %s", err)
+		}
+	}
+	if r.config.CompositeFilename != "" {
+		err := r.renderComposites(&jobs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render composite types: %s", err)
+		}
+	}
+	if r.config.GenerateDomainTypes && r.config.DomainFilename != "" {
+		err := r.renderDomains(&jobs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render domains: %s", err)
+		}
+	}
+	if r.config.TableFilename != "" {
+		err := r.renderTables(&jobs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render tables: %s", err)
+		}
+	}
+
+	err := checkFilenames(jobs)
+	if err != nil {
+		return nil, err
+	}
+
+	rendered := make([]bytes.Buffer, len(jobs))
+	errs := make([]error, len(jobs))
+	parallel(r.config.Workers, len(jobs), func(i int) {
+		// Each job gets its own copy of the templates and the names chosen
+		// so far, so that what it renders doesn't depend on what else has
+		// been rendered
+		jr := *r
+		jr.names = newNamer(r.names.mapping())
+		tpl, err := jobs[i].tpl.Clone()
+		if err == nil {
+			err = jobs[i].render(&jr, tpl.Funcs(jr.Funcs()), &rendered[i])
+		}
+		errs[i] = err
+	})
+
+	out := newOutput()
+	for i, j := range jobs {
+		if errs[i] != nil {
+			return nil, fmt.Errorf("failed to render %s: while rendering %s: %s", j.kind, j.name, errs[i])
+		}
+		_, _ = out.writer(j.filename).Write(rendered[i].Bytes())
+	}
+	err = out.format(r.config.Workers, r.importPaths())
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// checkFilenames makes sure that no two jobs write the same file, as each
+// renders a complete Go file of its own
+func checkFilenames(jobs []job) error {
+	seen := map[string]job{}
+	for _, j := range jobs {
+		previous, ok := seen[j.filename]
+		if !ok {
+			seen[j.filename] = j
+			continue
+		}
+		if previous.kind == j.kind {
+			return fmt.Errorf("%s %s and %s would both be generated in %s - change the filename setting so that they're different, e.g. using {{.Schema}} or {{.Alias}}", j.kind, previous.name, j.name, j.filename)
+		}
+		return fmt.Errorf("%s %s and %s %s would both be generated in %s - change the filename settings so that they're different", previous.kind, previous.name, j.kind, j.name, j.filename)
+	}
+	return nil
+}
+
+// parseTemplate reads and parses the template in filename
+func (r *Renderer) parseTemplate(name string, filename string) (*template.Template, error) {
+	tplSource, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	tpl, err := template.New(name).Funcs(r.Funcs()).Parse(string(tplSource))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s template: %s", name, err)
+	}
+	return tpl, nil
+}
+
+func (r *Renderer) renderEnums(jobs *[]job) error {
+	tpl, err := r.parseTemplate("enum", r.config.EnumTemplate)
+	if err != nil {
+		return err
+	}
+	for _, e := range r.result.Enums {
+		filename, err := r.enumFilename(e)
+		if err != nil {
+			return err
+		}
+		*jobs = append(*jobs, job{
+			kind:     "enums",
+			name:     e.Schema + "." + e.Name,
+			filename: filename,
+			tpl:      tpl,
+			render: func(r *Renderer, tpl *template.Template, w io.Writer) error {
+				return r.renderEnum(e, tpl, w)
+			},
+		})
+	}
+	return nil
+}
+
+func (r *Renderer) renderComposites(jobs *[]job) error {
+	tpl, err := r.parseTemplate("composite", r.config.CompositeTemplate)
+	if err != nil {
+		return err
+	}
+	for _, ct := range r.result.Composites {
+		filename, err := r.compositeFilename(ct)
+		if err != nil {
+			return err
+		}
+		*jobs = append(*jobs, job{
+			kind:     "composite types",
+			name:     ct.Schema + "." + ct.Name,
+			filename: filename,
+			tpl:      tpl,
+			render: func(r *Renderer, tpl *template.Template, w io.Writer) error {
+				return r.renderComposite(ct, tpl, w)
+			},
+		})
+	}
+	return nil
+}
+
+func (r *Renderer) renderDomains(jobs *[]job) error {
+	tpl, err := r.parseTemplate("domain", r.config.DomainTemplate)
+	if err != nil {
+		return err
+	}
+	for _, d := range r.result.Domains {
+		if !basicType(d.GoType) {
+			// Columns of this domain use its base type
+			continue
+		}
+		filename, err := r.domainFilename(d)
+		if err != nil {
+			return err
+		}
+		*jobs = append(*jobs, job{
+			kind:     "domains",
+			name:     d.Schema + "." + d.Name,
+			filename: filename,
+			tpl:      tpl,
+			render: func(r *Renderer, tpl *template.Template, w io.Writer) error {
+				return r.renderDomain(d, tpl, w)
+			},
+		})
+	}
+	return nil
+}
+
+func (r *Renderer) renderTables(jobs *[]job) error {
+	tpl, err := r.parseTemplate("table", r.config.TableTemplate)
+	if err != nil {
+		return err
+	}
+	for _, t := range r.result.Tables {
+		filename, err := r.tableFilename(t)
+		if err != nil {
+			return err
+		}
+		*jobs = append(*jobs, job{
+			kind:     "tables",
+			name:     t.Schema + "." + t.Name,
+			filename: filename,
+			tpl:      tpl,
+			render: func(r *Renderer, tpl *template.Template, w io.Writer) error {
+				return r.renderTable(t, tpl, w)
+			},
+		})
+	}
+	return nil
+}
+
+func (r *Renderer) renderEnum(e Enum, tpl *template.Template, w io.Writer) error {
+	return tpl.Execute(w, struct {
+		Enum   Enum
+		Schema Result
+		Param  map[string]interface{}
+	}{
+		Enum:   e,
+		Schema: r.result,
+		Param:  r.params(e.Schema),
+	})
+}
+
+func (r *Renderer) renderComposite(ct Composite, tpl *template.Template, w io.Writer) error {
+	imports, err := r.imports(ct.Schema, r.compositeTypes(ct))
+	if err != nil {
+		return err
+	}
+	return tpl.Execute(w, struct {
+		Composite Composite
+		Schema    Result
+		Param     map[string]interface{}
+		Imports   []string
+	}{
+		Composite: ct,
+		Schema:    r.result,
+		Param:     r.params(ct.Schema),
+		Imports:   imports,
+	})
+}
+
+func (r *Renderer) renderDomain(d Domain, tpl *template.Template, w io.Writer) error {
+	imports, err := r.imports(d.Schema, r.domainTypes(d))
+	if err != nil {
+		return err
+	}
+	return tpl.Execute(w, struct {
+		Domain  Domain
+		Schema  Result
+		Param   map[string]interface{}
+		Imports []string
+	}{
+		Domain:  d,
+		Schema:  r.result,
+		Param:   r.params(d.Schema),
+		Imports: imports,
+	})
+}
+
+func (r *Renderer) renderTable(t Table, tpl *template.Template, w io.Writer) error {
+	types, err := r.tableTypes(t)
+	if err != nil {
+		return err
+	}
+	imports, err := r.imports(t.Schema, types)
+	if err != nil {
+		return err
+	}
+	return tpl.Execute(w, struct {
+		Table   Table
+		Schema  Result
+		Param   map[string]interface{}
+		Imports []string
+	}{
+		Table:   t,
+		Schema:  r.result,
+		Param:   r.params(t.Schema),
+		Imports: imports,
+	})
+}
+
+// params returns the template parameters for code generated for schema,
+// with the package it's generated in
+func (r *Renderer) params(schema string) map[string]interface{} {
+	p := make(map[string]interface{}, len(r.config.TemplateParameters)+1)
+	for k, v := range r.config.TemplateParameters {
+		p[k] = v
+	}
+	p["package"] = r.config.Output(schema).Package
+	return p
+}
+
+// compositeTypes returns the Go types a composite type's code uses
+func (r *Renderer) compositeTypes(ct Composite) []string {
+	types := []string{}
+	for _, f := range ct.Fields {
+		types = append(types, f.GoType)
+	}
+	return types
+}
+
+// domainTypes returns the Go types a domain's code uses
+func (r *Renderer) domainTypes(d Domain) []string {
+	return []string{d.GoType}
+}
+
+// tableTypes returns the Go types a table's code uses, including the
+// tables in other packages that its foreign keys refer to
+func (r *Renderer) tableTypes(t Table) ([]string, error) {
+	types := []string{}
+	for _, f := range t.Fields {
+		types = append(types, f.GoType)
+	}
+	for _, q := range t.Queries {
+		for _, f := range q.Fields {
+			types = append(types, f.GoType)
+		}
+		for _, f := range q.Parameters {
+			types = append(types, f.GoType)
+		}
+	}
+	for _, fk := range t.ForeignKeys {
+		if fk.ParentMethod == "" || fk.ForeignPackage == "" {
+			continue
+		}
+		parent, err := r.table(fk.ForeignSchema, fk.ForeignTable)
+		if err != nil {
+			return nil, err
+		}
+		types = append(types, qualify(fk.ForeignPackage, r.names.goname(parent.Alias)))
+	}
+	return types, nil
+}
+
+var qualifiedTypeRe = regexp.MustCompile(`\b([A-Za-z_][A-Za-z0-9_]*)\.[A-Za-z_]`)
+
+// imports returns the import paths of the other generated packages that
+// code generated for schema uses, based on the package names in the Go
+// types it uses
+func (r *Renderer) imports(schema string, types []string) ([]string, error) {
+	own := r.config.Output(schema)
+	packages := map[string]bool{}
+	for _, t := range types {
+		for _, m := range qualifiedTypeRe.FindAllStringSubmatch(t, -1) {
+			packages[m[1]] = true
+		}
+	}
+
+	schemas := []string{""}
+	for s := range r.config.Schema {
+		schemas = append(schemas, s)
+	}
+	sort.Strings(schemas)
+
+	found := map[string]bool{}
+	imports := []string{}
+	for _, s := range schemas {
+		o := r.config.Output(s)
+		if o.Directory == own.Directory || !packages[o.Package] || found[o.Directory] {
+			continue
+		}
+		found[o.Directory] = true
+		path := o.ImportPath
+		if path == "" {
+			var err error
+			path, err = importPath(o.Directory)
+			if err != nil {
+				return nil, fmt.Errorf("can't find the import path for %s, set ImportPath for schema %s: %s", o.Directory, s, err)
+			}
+		}
+		imports = append(imports, path)
+	}
+	sort.Strings(imports)
+	return imports, nil
+}
+
+// importPath works out the import path of a directory from the module path
+// in the go.mod file in it or its closest parent
+func importPath(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for moddir := abs; ; moddir = filepath.Dir(moddir) {
+		gomod, err := ioutil.ReadFile(filepath.Join(moddir, "go.mod"))
+		if err == nil {
+			module := ""
+			for _, line := range strings.Split(string(gomod), "\n") {
+				fields := strings.Fields(line)
+				if len(fields) == 2 && fields[0] == "module" {
+					module = strings.Trim(fields[1], `"`)
+				}
+			}
+			if module == "" {
+				return "", fmt.Errorf("no module path in %s", filepath.Join(moddir, "go.mod"))
+			}
+			rel, err := filepath.Rel(moddir, abs)
+			if err != nil {
+				return "", err
+			}
+			return path.Join(module, filepath.ToSlash(rel)), nil
+		}
+		if filepath.Dir(moddir) == moddir {
+			return "", fmt.Errorf("no go.mod found")
+		}
+	}
+}
+
+func (r *Renderer) tableFilename(t Table) (string, error) {
+	return r.outputFilename(t.Schema, "TableFilename", r.config.TableFilename, t)
+}
+
+func (r *Renderer) enumFilename(e Enum) (string, error) {
+	return r.outputFilename(e.Schema, "EnumFilename", r.config.EnumFilename, e)
+}
+
+func (r *Renderer) compositeFilename(ct Composite) (string, error) {
+	return r.outputFilename(ct.Schema, "CompositeFilename", r.config.CompositeFilename, ct)
+}
+
+func (r *Renderer) domainFilename(d Domain) (string, error) {
+	return r.outputFilename(d.Schema, "DomainFilename", r.config.DomainFilename, d)
+}
+
+// outputFilename expands the filename template from setting for data, in
+// the output directory for schema
+func (r *Renderer) outputFilename(schema string, setting string, tpl string, data interface{}) (string, error) {
+	filename, err := expandFilename(setting, tpl, data)
+	if err != nil || filename == "" {
+		return filename, err
+	}
+	return filepath.Join(r.config.Output(schema).Directory, filename), nil
+}
+
+// expandFilename expands the filename template from setting for data
+func expandFilename(setting string, tpl string, data interface{}) (string, error) {
+	if tpl == "" {
+		return "", nil
+	}
+	filenameTemplate, err := template.New("filename").Parse(tpl)
+	if err != nil {
+		return "", fmt.Errorf("bad template for %s: %s", setting, err)
+	}
+	var b bytes.Buffer
+	err = filenameTemplate.Execute(&b, data)
+	if err != nil {
+		return "", fmt.Errorf("%s template failed: %s", setting, err)
+	}
+	return b.String(), nil
+}
+
+// manifestFilename returns the name of the file that lists the files
+// we've generated
+func (r *Renderer) manifestFilename() string {
+	if r.config.Manifest != "" {
+		return r.config.Manifest
+	}
+	return "mro.manifest"
+}
+
+// readManifest returns the files listed in the manifest, or nothing if
+// there isn't one
+func (r *Renderer) readManifest() ([]string, error) {
+	content, err := ioutil.ReadFile(r.manifestFilename())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	filenames := []string{}
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		filenames = append(filenames, line)
+	}
+	return filenames, nil
+}
+
+// manifest returns the content of the manifest for out
+func manifest(out *Output) []byte {
+	filenames := append([]string{}, out.Names...)
+	sort.Strings(filenames)
+	var b bytes.Buffer
+	b.WriteString("# Files generated by mro. They're deleted by mro -clean and when regenerating.\n")
+	for _, filename := range filenames {
+		b.WriteString(filename + "\n")
+	}
+	return b.Bytes()
+}
+
+// checkGenerated makes sure that a file is safe to delete or overwrite,
+// either because it doesn't exist or because its first line is
+// GeneratedHeader, as it is in everything we write
+func checkGenerated(filename string) error {
+	content, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	firstLine := string(content)
+	if i := strings.IndexByte(firstLine, '\n'); i != -1 {
+		firstLine = firstLine[:i]
+	}
+	if strings.TrimSuffix(firstLine, "\r") == GeneratedHeader {
+		return nil
+	}
+	return fmt.Errorf("%s wasn't generated by mro, so it won't be deleted or overwritten", filename)
+}
+
+// removeGenerated deletes files, once it's checked that they were all
+// generated by mro
+func removeGenerated(filenames []string) error {
+	for _, filename := range filenames {
+		err := checkGenerated(filename)
+		if err != nil {
+			return err
+		}
+	}
+	for _, filename := range filenames {
+		err := os.Remove(filename)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Clean deletes the files listed in the manifest, and the manifest itself.
+// Files that don't have GeneratedHeader are left alone.
+func (r *Renderer) Clean() error {
+	previous, err := r.readManifest()
+	if err != nil {
+		return err
+	}
+	err = removeGenerated(previous)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(r.manifestFilename())
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Tidy runs the PostProcess commands on filename
+func (r *Renderer) Tidy(filename string) error {
+	for _, pp := range r.config.PostProcess {
+		commandline := strings.Split(pp, " ")
+		commandline = append(commandline, filename)
+		cmd := exec.Command(commandline[0], commandline[1:]...)
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("'%s' failed: %s\n%s", strings.Join(commandline, " "), err, output)
+		}
+	}
+	return nil
+}
+
+// stale returns the files in the manifest that out doesn't include
+func (r *Renderer) stale(out *Output) ([]string, error) {
+	previous, err := r.readManifest()
+	if err != nil {
+		return nil, err
+	}
+	stale := []string{}
+	for _, filename := range previous {
+		if _, ok := out.Content[filename]; !ok {
+			stale = append(stale, filename)
+		}
+	}
+	return stale, nil
+}
+
+// Write writes rendered files to disk and post-processes them, deletes
+// files listed in the manifest that are no longer generated, and updates
+// the manifest. It won't delete or overwrite files that don't have
+// GeneratedHeader, and checks that before changing anything. Every file is
+// written and post-processed alongside the one it replaces before any of
+// them are renamed into place, so if anything fails up to then the files
+// on disk are left as they were.
+func (r *Renderer) Write(out *Output) error {
+	stale, err := r.stale(out)
+	if err != nil {
+		return err
+	}
+	for _, filename := range append(append([]string{}, out.Names...), stale...) {
+		err = checkGenerated(filename)
+		if err != nil {
+			return err
+		}
+	}
+
+	tmpnames := make([]string, 0, len(out.Names))
+	defer func() {
+		// Anything that's been renamed into place has gone already
+		for _, tmpname := range tmpnames {
+			os.Remove(tmpname)
+		}
+	}()
+	for _, filename := range out.Names {
+		tmpname, err := r.stageFile(filename, out.Content[filename].Bytes(), true)
+		if err != nil {
+			return err
+		}
+		tmpnames = append(tmpnames, tmpname)
+	}
+	for i, filename := range out.Names {
+		err = os.Rename(tmpnames[i], filename)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = removeGenerated(stale)
+	if err != nil {
+		return err
+	}
+	return r.writeFile(r.manifestFilename(), manifest(out), false)
+}
+
+// writeFile replaces filename atomically, by writing content to a
+// temporary file alongside it, optionally running the PostProcess commands
+// on that, then renaming it. If anything fails filename is left as it was.
+func (r *Renderer) writeFile(filename string, content []byte, tidy bool) error {
+	tmpname, err := r.stageFile(filename, content, tidy)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpname)
+	return os.Rename(tmpname, filename)
+}
+
+// stageFile writes content to a temporary file alongside filename, ready
+// to be renamed over it, and optionally runs the PostProcess commands on
+// it. It returns the name of the temporary file, which is removed if
+// anything fails.
+func (r *Renderer) stageFile(filename string, content []byte, tidy bool) (string, error) {
+	dir := filepath.Dir(filename)
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return "", err
+	}
+	// The temporary file doesn't end in .go, so it's not mistaken for part
+	// of the package while it's being post-processed
+	tmp, err := ioutil.TempFile(dir, "."+filepath.Base(filename)+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+	tmpname := tmp.Name()
+
+	_, err = tmp.Write(content)
+	if err == nil {
+		err = tmp.Chmod(0644)
+	}
+	closeErr := tmp.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil && tidy {
+		err = r.Tidy(tmpname)
+	}
+	if err != nil {
+		os.Remove(tmpname)
+		return "", err
+	}
+	return tmpname, nil
+}
+
+// Check post-processes copies of rendered files and compares them with
+// what's on disk. It returns the names of any files that are missing or
+// differ, along with any that would be deleted and the manifest if it
+// would change. Each output directory is copied to a temporary directory,
+// and files are staged and post-processed there the same way Write does,
+// so post-processors that look at the rest of the package see the same
+// files.
+func (r *Renderer) Check(out *Output) ([]string, error) {
+	tmpdir, err := ioutil.TempDir("", "mro")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpdir)
+
+	copies := map[string]string{}
+	stale := []string{}
+	for _, filename := range out.Names {
+		dir := filepath.Dir(filename)
+		copied, ok := copies[dir]
+		if !ok {
+			copied = filepath.Join(tmpdir, strconv.Itoa(len(copies)))
+			err = copyFiles(dir, copied)
+			if err != nil {
+				return nil, err
+			}
+			copies[dir] = copied
+		}
+		// Staged files are left until the end, as Write leaves them until
+		// everything has been post-processed
+		tmpname, err := r.stageFile(filepath.Join(copied, filepath.Base(filename)), out.Content[filename].Bytes(), true)
+		if err != nil {
+			return nil, err
+		}
+
+		want, err := ioutil.ReadFile(tmpname)
+		if err != nil {
+			return nil, err
+		}
+		have, err := ioutil.ReadFile(filename)
+		if err != nil || !bytes.Equal(want, have) {
+			stale = append(stale, filename)
+		}
+	}
+
+	deleted, err := r.stale(out)
+	if err != nil {
+		return nil, err
+	}
+	stale = append(stale, deleted...)
+	have, err := ioutil.ReadFile(r.manifestFilename())
+	if err != nil || !bytes.Equal(manifest(out), have) {
+		stale = append(stale, r.manifestFilename())
+	}
+	return stale, nil
+}
+
+// copyFiles copies the regular files in dir, if it exists, to a new
+// directory to
+func copyFiles(dir string, to string) error {
+	err := os.MkdirAll(to, 0755)
+	if err != nil {
+		return err
+	}
+	entries, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !entry.Mode().IsRegular() {
+			continue
+		}
+		content, err := ioutil.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		err = ioutil.WriteFile(filepath.Join(to, entry.Name()), content, entry.Mode().Perm())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,297 @@
+package mro
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestRenderer returns a Renderer whose manifest is in dir
+func newTestRenderer(dir string) *Renderer {
+	return NewRenderer(Config{Manifest: filepath.Join(dir, "mro.manifest")}, Result{})
+}
+
+// writeTestFile creates filename with content, failing the test if it can't
+func writeTestFile(t *testing.T, filename string, content string) {
+	t.Helper()
+	err := ioutil.WriteFile(filename, []byte(content), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testOutput returns an Output with a generated file for each of filenames
+func testOutput(filenames ...string) *Output {
+	out := newOutput()
+	for _, filename := range filenames {
+		fmt.Fprintf(out.writer(filename), "package test\n")
+	}
+	return out
+}
+
+func exists(filename string) bool {
+	_, err := os.Stat(filename)
+	return err == nil
+}
+
+func TestWriteRemovesStale(t *testing.T) {
+	dir := t.TempDir()
+	r := newTestRenderer(dir)
+	a := filepath.Join(dir, "a.mro.go")
+	b := filepath.Join(dir, "b.mro.go")
+
+	err := r.Write(testOutput(a, b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.Write(testOutput(a))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !exists(a) {
+		t.Errorf("%s was removed", a)
+	}
+	if exists(b) {
+		t.Errorf("stale %s wasn't removed", b)
+	}
+	previous, err := r.readManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(previous) != 1 || previous[0] != a {
+		t.Errorf("manifest lists %v, expected just %s", previous, a)
+	}
+}
+
+func TestWriteRefusesHandWritten(t *testing.T) {
+	handWritten := []string{
+		"package test\n",
+		"package test\n\n// " + GeneratedHeader + "\n",
+		"package test\n\nconst header = `\n" + GeneratedHeader + "\n`\n",
+		"  " + GeneratedHeader + "\n\npackage test\n",
+	}
+	for _, content := range handWritten {
+		// Overwriting
+		dir := t.TempDir()
+		r := newTestRenderer(dir)
+		a := filepath.Join(dir, "a.mro.go")
+		writeTestFile(t, a, content)
+		err := r.Write(testOutput(a))
+		if err == nil {
+			t.Errorf("overwrote a hand-written file starting %q", content)
+		}
+		got, _ := ioutil.ReadFile(a)
+		if string(got) != content {
+			t.Errorf("hand-written file changed to %q", got)
+		}
+
+		// Deleting
+		dir = t.TempDir()
+		r = newTestRenderer(dir)
+		a = filepath.Join(dir, "a.mro.go")
+		b := filepath.Join(dir, "b.mro.go")
+		writeTestFile(t, r.manifestFilename(), b+"\n")
+		writeTestFile(t, b, content)
+		err = r.Write(testOutput(a))
+		if err == nil {
+			t.Errorf("deleted a hand-written file starting %q", content)
+		}
+		if !exists(b) {
+			t.Errorf("hand-written %s was deleted", b)
+		}
+		if exists(a) {
+			t.Errorf("%s was written, though %s couldn't be deleted", a, b)
+		}
+	}
+}
+
+func TestWriteFailureChangesNothing(t *testing.T) {
+	dir := t.TempDir()
+	r := newTestRenderer(dir)
+	a := filepath.Join(dir, "a.mro.go")
+	b := filepath.Join(dir, "b.mro.go")
+	err := r.Write(testOutput(a, b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	before, err := ioutil.ReadFile(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Post-processing fails for the second file, after the first one has
+	// been post-processed
+	c := filepath.Join(dir, "c.mro.go")
+	out := newOutput()
+	fmt.Fprintf(out.writer(a), "package changed\n")
+	fmt.Fprintf(out.writer(c), "broken\n")
+	r.config.PostProcess = []string{"grep -q package"}
+	err = r.Write(out)
+	if err == nil {
+		t.Fatal("a failing PostProcess command wasn't reported")
+	}
+	after, _ := ioutil.ReadFile(a)
+	if string(after) != string(before) {
+		t.Errorf("%s changed to %q", a, after)
+	}
+	if !exists(b) {
+		t.Errorf("stale %s was removed", b)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*"))
+	hidden, _ := filepath.Glob(filepath.Join(dir, ".*"))
+	if len(files) != 3 || len(hidden) != 0 {
+		t.Errorf("expected just two files and the manifest, found %v", append(files, hidden...))
+	}
+}
+
+func TestCleanOnlyRemovesManifest(t *testing.T) {
+	dir := t.TempDir()
+	r := newTestRenderer(dir)
+	a := filepath.Join(dir, "a.mro.go")
+	b := filepath.Join(dir, "b.mro.go")
+
+	err := r.Write(testOutput(a))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// b looks generated, but isn't in the manifest
+	writeTestFile(t, b, GeneratedHeader+"\n\npackage test\n")
+
+	err = r.Clean()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exists(a) {
+		t.Errorf("%s wasn't removed", a)
+	}
+	if !exists(b) {
+		t.Errorf("%s was removed, but it isn't in the manifest", b)
+	}
+	if exists(r.manifestFilename()) {
+		t.Errorf("manifest wasn't removed")
+	}
+}
+
+func TestMissingManifest(t *testing.T) {
+	dir := t.TempDir()
+	r := newTestRenderer(dir)
+	a := filepath.Join(dir, "a.mro.go")
+	writeTestFile(t, a, GeneratedHeader+"\n\npackage test\n")
+
+	err := r.Clean()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !exists(a) {
+		t.Errorf("%s was removed without a manifest", a)
+	}
+	stale, err := r.stale(testOutput())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stale) != 0 {
+		t.Errorf("found stale files %v without a manifest", stale)
+	}
+
+	err = r.Write(testOutput(a))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := ioutil.ReadFile(a)
+	if !strings.HasPrefix(string(got), GeneratedHeader+"\n") {
+		t.Errorf("%s wasn't written", a)
+	}
+}
+
+func TestRenderRefusesSharedFilename(t *testing.T) {
+	dir := t.TempDir()
+	tpl := filepath.Join(dir, "table.tpl")
+	writeTestFile(t, tpl, "package test\n\ntype {{goname .Table.Alias}} struct{}\n")
+	result := Result{Tables: []Table{
+		{Schema: "billing", Name: "account", Alias: "billing_account"},
+		{Schema: "public", Name: "account", Alias: "public_account"},
+	}}
+
+	c := Config{TableTemplate: tpl, TableFilename: "{{.Name}}.mro.go"}
+	_, err := NewRenderer(c, result).Render()
+	if err == nil {
+		t.Fatal("two tables were rendered to the same file")
+	}
+	for _, name := range []string{"billing.account", "public.account", "account.mro.go"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("error %q doesn't mention %s", err, name)
+		}
+	}
+
+	c.TableFilename = "{{.Alias}}.mro.go"
+	out, err := NewRenderer(c, result).Render()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Names) != 2 {
+		t.Errorf("expected two files, got %v", out.Names)
+	}
+}
+
+func TestCheckAfterWrite(t *testing.T) {
+	dir := t.TempDir()
+	r := newTestRenderer(dir)
+	a := filepath.Join(dir, "a.mro.go")
+	b := filepath.Join(dir, "b.mro.go")
+	writeTestFile(t, filepath.Join(dir, "handwritten.go"), "package test\n")
+
+	// A post-processor that looks at the rest of the package, by counting
+	// the Go files alongside the one it's given
+	script := filepath.Join(t.TempDir(), "count.sh")
+	writeTestFile(t, script, `echo "// $(ls "$(dirname "$1")" | grep -c '\.go$')" >> "$1"`+"\n")
+	r.config.PostProcess = []string{"sh " + script}
+
+	// The first Write adds files to the package, the second is stable
+	for i := 0; i < 2; i++ {
+		err := r.Write(testOutput(a, b))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	stale, err := r.Check(testOutput(a, b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stale) != 0 {
+		t.Errorf("files %v are out of date straight after they were written", stale)
+	}
+
+	stale, err = r.Check(testOutput(a))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stale) != 2 || stale[0] != b || stale[1] != r.manifestFilename() {
+		t.Errorf("expected %s and the manifest to be out of date, got %v", b, stale)
+	}
+}
+
+func TestTableFilenameFollowsRename(t *testing.T) {
+	dir := t.TempDir()
+	tpl := filepath.Join(dir, "table.tpl")
+	writeTestFile(t, tpl, "package test\n\ntype {{goname .Table.Alias}} struct{}\n")
+	// Alias is filled in from Rename when the database is introspected
+	result := Result{Tables: []Table{{Schema: "public", Name: "tbl_usr_2", Alias: "user"}}}
+
+	tests := map[string]string{
+		"{{.Alias}}.mro.go": "user.mro.go",
+		"{{.Name}}.mro.go":  "tbl_usr_2.mro.go",
+	}
+	for setting, want := range tests {
+		c := Config{TableTemplate: tpl, TableFilename: setting}
+		out, err := NewRenderer(c, result).Render()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(out.Names) != 1 || out.Names[0] != want {
+			t.Errorf("TableFilename %s generated %v, expected %s", setting, out.Names, want)
+		}
+	}
+}
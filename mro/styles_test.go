@@ -0,0 +1,377 @@
+package mro
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/hashicorp/hcl"
+)
+
+// styles are the styles built in to mro, from the styles directory at the
+// top of the repository
+var styles = []string{"pgx", "pgx5", "sql"}
+
+// styleConfig reads the configuration a style bootstraps, pointing its
+// templates at the style directory
+func styleConfig(t *testing.T, style string) Config {
+	t.Helper()
+	dir := filepath.Join("..", "styles", style)
+	var b bytes.Buffer
+	bootstrapTemplate(t, filepath.Join(dir, "mro.cfg.mrotpl"), &b)
+	var c Config
+	err := hcl.Unmarshal(b.Bytes(), &c)
+	if err != nil {
+		t.Fatalf("failed to read %s configuration: %s", style, err)
+	}
+	c.EnumTemplate = filepath.Join(dir, c.EnumTemplate)
+	c.CompositeTemplate = filepath.Join(dir, c.CompositeTemplate)
+	c.DomainTemplate = filepath.Join(dir, c.DomainTemplate)
+	c.TableTemplate = filepath.Join(dir, c.TableTemplate)
+	c.TemplateParameters = map[string]interface{}{"package": "gen"}
+	return c
+}
+
+// bootstrapTemplate expands a style's .mrotpl file, as mro --bootstrap does
+func bootstrapTemplate(t *testing.T, filename string, w *bytes.Buffer) {
+	t.Helper()
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	funcs := NewRenderer(Config{}, Result{}).Funcs()
+	tpl, err := template.New("global").Funcs(funcs).Delims("[[", "]]").Parse(string(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = tpl.Execute(w, map[string]interface{}{"package": "gen"})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// styleType returns the Go type a style's type maps give a column
+func styleType(c Config, typename string, notnull bool) string {
+	if gt, ok := c.NotNullTypes[typename]; ok && notnull {
+		return gt
+	}
+	return c.Types[typename]
+}
+
+// testResult returns the introspection result for a small schema, with
+// Go types from the type maps in c:
+//
+//	create type mood as enum ('happy', 'sad', 'not sure');
+//	create type address as (street text not null, zip text);
+//	create domain email as text check (value ~ '@');
+//	create domain moment as timestamptz;
+//	create table account (
+//	  id bigserial primary key,
+//	  name text not null,
+//	  email email,
+//	  mood mood not null,
+//	  address address,
+//	  created timestamptz not null default now(),
+//	  total bigint generated always as (0) stored
+//	);
+//	create table orders (
+//	  id bigint generated always as identity primary key,
+//	  account_id bigint not null references account(id),
+//	  note text,
+//	  mood mood,
+//	  clerk_id bigint references audit.clerk(id),
+//	  placed moment not null
+//	);
+//	create table account_tag (
+//	  account_id bigint not null references account(id),
+//	  tag text not null,
+//	  primary key (account_id, tag)
+//	);
+//
+// where the audit schema isn't included, along with queries that return a
+// single row, many rows, rows of their own type and no rows.
+func testResult(t *testing.T, c Config) Result {
+	t.Helper()
+	in := NewIntrospector(c, nil)
+	in.names = newNamer(nil)
+	names := in.names
+	field := func(position int, name string, typename string, notnull bool) Field {
+		return Field{
+			Name:     name,
+			Position: position,
+			Type:     typename,
+			NotNull:  notnull,
+			GoType:   styleType(c, typename, notnull),
+			Visible:  true,
+		}
+	}
+	// typed gives a field one of the generated enum, composite or domain types
+	typed := func(position int, name string, typename string, oid uint32, notnull bool) Field {
+		f := field(position, name, typename, notnull)
+		f.TypeID = oid
+		f.GoType = names.goname(typename)
+		if !notnull {
+			f.GoType = "Null" + f.GoType
+		}
+		return f
+	}
+	serial := func(f Field) Field {
+		f.HasDefault = true
+		return f
+	}
+	table := func(oid uint32, name string, key int, fields ...Field) Table {
+		pkey := Unique{Name: name + "_pkey", PrimaryKey: true, Columns: fieldNames(fields[:key])}
+		t := Table{
+			OID:           oid,
+			Name:          name,
+			Schema:        "public",
+			Alias:         name,
+			Type:          "r",
+			Fields:        fields,
+			Indexes:       []Unique{pkey},
+			Primary:       pkey,
+			PrimaryFields: fields[:key],
+		}
+		if key == 1 && fields[0].HasDefault {
+			t.IDField = fields[0]
+		}
+		return t
+	}
+	fk := func(name string, column string) ForeignKey {
+		return ForeignKey{
+			Name:           name,
+			Columns:        []string{column},
+			ForeignSchema:  "public",
+			ForeignTable:   "account",
+			ForeignColumns: []string{"id"},
+		}
+	}
+
+	mood := Enum{OID: 2000, Name: "mood", Schema: "public", Labels: []string{"happy", "sad", "not sure"}}
+	address := Composite{OID: 2001, Name: "address", Schema: "public", Fields: []Field{
+		field(1, "street", "text", true),
+		field(2, "zip", "text", false),
+	}}
+	email := Domain{OID: 2002, Name: "email", Schema: "public", BaseType: "text", BaseTypeID: 25,
+		GoType: styleType(c, "text", true), Checks: []string{"CHECK ((VALUE ~ '@'::text))"}}
+	// A named type can't be based on time.Time, so moment columns use it
+	// directly
+	moment := Domain{OID: 2003, Name: "moment", Schema: "public", BaseType: "timestamptz", BaseTypeID: 1184,
+		GoType: styleType(c, "timestamptz", true)}
+
+	total := field(7, "total", "bigint", false)
+	total.Generated = true
+	account := table(1000, "account", 1,
+		serial(field(1, "id", "bigint", true)),
+		field(2, "name", "text", true),
+		typed(3, "email", "email", email.OID, false),
+		typed(4, "mood", "mood", mood.OID, true),
+		typed(5, "address", "address", address.OID, false),
+		serial(field(6, "created", "timestamptz", true)),
+		total)
+	id := serial(field(1, "id", "bigint", true))
+	id.IdentityAlways = true
+	orders := table(1001, "orders", 1,
+		id,
+		field(2, "account_id", "bigint", true),
+		field(3, "note", "text", false),
+		typed(4, "mood", "mood", mood.OID, false),
+		field(5, "clerk_id", "bigint", false),
+		field(6, "placed", "timestamptz", true))
+	clerk := fk("orders_clerk_id_fkey", "clerk_id")
+	clerk.ForeignSchema = "audit"
+	clerk.ForeignTable = "clerk"
+	orders.ForeignKeys = []ForeignKey{fk("orders_account_id_fkey", "account_id"), clerk}
+	tag := table(1002, "account_tag", 2,
+		field(1, "account_id", "bigint", true),
+		field(2, "tag", "text", true))
+	tag.ForeignKeys = []ForeignKey{fk("account_tag_account_id_fkey", "account_id")}
+	tag.Queries = []Query{{
+		// A parameter with the same name as a variable in the generated code
+		Name:          "DeleteTag",
+		Query:         "delete from account_tag where tag = $1",
+		OriginalQuery: "delete from account_tag where tag = $1",
+		Parameters:    []Field{field(1, "tag", "text", true)},
+		Exec:          true,
+	}}
+
+	param := func(position int, name string, typename string) Field {
+		return field(position, name, typename, true)
+	}
+	account.Queries = []Query{{
+		Name:          "AccountByID",
+		Query:         "select id, name, email, mood, address, created, total from account where id = $1",
+		OriginalQuery: "select * from account where id = $1",
+		Fields:        account.Fields,
+		Parameters:    []Field{param(1, "id", "bigint")},
+		SingleRow:     true,
+	}, {
+		Name:          "AccountMoods",
+		Query:         "select mood, count(*) as n from account where name like $1 group by mood",
+		OriginalQuery: "select mood, count(*) as n from account where name like $1 group by mood",
+		Fields:        []Field{typed(1, "mood", "mood", mood.OID, true), field(2, "n", "bigint", false)},
+		Parameters:    []Field{param(1, "name", "text")},
+		ResultType:    "AccountMoodsRow",
+	}, {
+		Name:          "RenameAccount",
+		Query:         "update account set name = $1 where id = $2",
+		OriginalQuery: "update account set name = $1 where id = $2",
+		Parameters:    []Field{param(1, "name", "text"), param(2, "id", "bigint")},
+		Exec:          true,
+	}, {
+		Name:          "AccountOrders",
+		Query:         "select a.name, o.note from account a join orders o on o.account_id = a.id where o.id = $1",
+		OriginalQuery: "select a.name, o.note from account a join orders o on o.account_id = a.id where o.id = $1",
+		Fields:        []Field{field(1, "name", "text", true), field(2, "note", "text", false)},
+		Parameters:    []Field{param(1, in.parameterName("o.id", 1), "bigint")},
+		ResultType:    "AccountOrdersRow",
+	}}
+	orders.Queries = []Query{{
+		Name:          "OrdersByNote",
+		Query:         "select id, account_id, note, mood, clerk_id, placed from orders where note = $1",
+		OriginalQuery: "select * from orders where note = $1",
+		Fields:        orders.Fields,
+		Parameters:    []Field{param(1, "note", "text")},
+	}}
+
+	in.result = Result{
+		Tables:     []Table{account, orders, tag},
+		Enums:      []Enum{mood},
+		Composites: []Composite{address},
+		Domains:    []Domain{email, moment},
+	}
+	err := in.nameRelations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	in.result.GoNames = in.names.mapping()
+	return in.result
+}
+
+// buildStyle renders result with a style, and builds the generated code
+// along with the files the style bootstraps
+func buildStyle(t *testing.T, style string, c Config, result Result) map[string]string {
+	t.Helper()
+	gobin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("no go command to build generated code with")
+	}
+
+	out, err := NewRenderer(c, result).Render()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	files := map[string]string{}
+	for _, filename := range out.Names {
+		files[filename] = out.Content[filename].String()
+		writeTestFile(t, filepath.Join(dir, filename), files[filename])
+	}
+	bootstraps, err := filepath.Glob(filepath.Join("..", "styles", style, "*.go.mrotpl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, filename := range bootstraps {
+		var b bytes.Buffer
+		bootstrapTemplate(t, filename, &b)
+		writeTestFile(t, filepath.Join(dir, strings.TrimSuffix(filepath.Base(filename), ".mrotpl")), b.String())
+	}
+	// Start from mro's own modules, which include pgx v3 and its
+	// dependencies
+	gomod, err := ioutil.ReadFile(filepath.Join("..", "go.mod"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gosum, err := ioutil.ReadFile(filepath.Join("..", "go.sum"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(dir, "go.mod"), strings.Replace(string(gomod), "module github.com/wttw/mro", "module example.com/gen", 1))
+	writeTestFile(t, filepath.Join(dir, "go.sum"), string(gosum))
+
+	goCommand := func(args ...string) ([]byte, error) {
+		cmd := exec.Command(gobin, args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod")
+		return cmd.CombinedOutput()
+	}
+	output, err := goCommand("mod", "tidy")
+	if err != nil {
+		t.Skipf("can't fetch the modules generated code uses: %s\n%s", err, output)
+	}
+	output, err = goCommand("build", "./...")
+	if err != nil {
+		t.Fatalf("generated code doesn't build: %s\n%s", err, output)
+	}
+	return files
+}
+
+// generatedCode is what every style generates for testResult
+var generatedCode = []string{
+	// Writes
+	"func (t *Account) Insert(ctx context.Context",
+	"func (t *Account) InsertDefaults(ctx context.Context",
+	"func (t *Account) Update(ctx context.Context",
+	"func (t *Account) UpdateChanged(ctx context.Context",
+	"func (t *Account) Upsert(ctx context.Context",
+	"func (t *Account) Delete(ctx context.Context",
+	"func (t *Account) SetName(",
+	"t.mroSet = [6]bool{}",
+	"func (t *AccountTag) Insert(ctx context.Context",
+	"func (t *AccountTag) Upsert(ctx context.Context",
+	// Foreign keys
+	"func (t *Orders) Account(ctx context.Context",
+	"func LoadAccountForOrders(ctx context.Context",
+	"func (t *Account) Orders(ctx context.Context",
+	"func LoadOrdersForAccount(ctx context.Context",
+	"func (t *Account) AccountTag(ctx context.Context",
+	"func LoadAccountTagForAccount(ctx context.Context",
+	// Queries
+	"func AccountByID(ctx context.Context",
+	"type AccountMoodsRow struct",
+	"func AccountMoods(ctx context.Context",
+	"func RenameAccount(ctx context.Context",
+	"func OrdersByNote(ctx context.Context",
+	"func AccountOrders(ctx context.Context, db ",
+	") ([]AccountOrdersRow, error)",
+	"func DeleteTag(ctx context.Context",
+	// Types
+	"type Mood uint16",
+	"MoodHappy Mood = iota",
+	"type NullMood struct",
+	"type Address struct",
+	"type NullAddress struct",
+	"type Email string",
+	"type NullEmail struct",
+}
+
+func TestStylesBuild(t *testing.T) {
+	if testing.Short() {
+		t.Skip("building generated code is slow")
+	}
+	for _, style := range styles {
+		t.Run(style, func(t *testing.T) {
+			c := styleConfig(t, style)
+			c.GenerateDomainTypes = true
+			files := buildStyle(t, style, c, testResult(t, c))
+			var all strings.Builder
+			for _, content := range files {
+				all.WriteString(content)
+			}
+			for _, want := range generatedCode {
+				if !strings.Contains(all.String(), want) {
+					t.Errorf("generated code doesn't include %q", want)
+				}
+			}
+			if strings.Contains(all.String(), "type Moment ") {
+				t.Errorf("generated a named type for a domain based on %s", styleType(c, "timestamptz", true))
+			}
+		})
+	}
+}
@@ -1,16 +1,84 @@
 package main
 
 import (
+	"embed"
+	"errors"
 	"fmt"
+	"io/fs"
 	"io/ioutil"
 	"log"
 	"os"
 	"path"
+	"path/filepath"
 	"strings"
 	"text/template"
+
+	"github.com/wttw/mro/mro"
 )
 
-//go:generate go-bindata -prefix styles styles/...
+//go:embed styles
+var builtinStyles embed.FS
+
+// styleSource is a directory of styles, either built in to mro or from
+// MRO_STYLE_PATH
+type styleSource struct {
+	name   string
+	styles fs.FS
+}
+
+// styleSources returns where to look for styles, in order. Directories in
+// MRO_STYLE_PATH come first, so they can replace a built in style.
+func styleSources() []styleSource {
+	sources := []styleSource{}
+	for _, dir := range filepath.SplitList(os.Getenv("MRO_STYLE_PATH")) {
+		if dir != "" {
+			sources = append(sources, styleSource{name: dir, styles: os.DirFS(dir)})
+		}
+	}
+	builtin, err := fs.Sub(builtinStyles, "styles")
+	if err != nil {
+		log.Fatalf("Failed to load built in styles: %s\n", err)
+	}
+	return append(sources, styleSource{name: "built in", styles: builtin})
+}
+
+// findStyle returns the files for a style. A style containing a path
+// separator is the path to a style directory, otherwise it's looked for in
+// MRO_STYLE_PATH and then in the styles built in to mro.
+func findStyle(style string) (fs.FS, error) {
+	if strings.ContainsRune(style, '/') || strings.ContainsRune(style, filepath.Separator) {
+		info, err := os.Stat(style)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("%s is not a directory", style)
+		}
+		return os.DirFS(style), nil
+	}
+	if !fs.ValidPath(style) {
+		return nil, fmt.Errorf("invalid style name '%s'", style)
+	}
+	for _, source := range styleSources() {
+		info, err := fs.Stat(source.styles, style)
+		if err == nil && info.IsDir() {
+			return fs.Sub(source.styles, style)
+		}
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("no style called '%s'", style)
+}
+
+// bootstrapFilename returns the name of the file that bootstrap creates from
+// a file in a style, or "" if it doesn't create one
+func bootstrapFilename(filename string) string {
+	if filename == "description.txt" {
+		return ""
+	}
+	return strings.TrimSuffix(filename, ".mrotpl")
+}
 
 func bootstrap(style string) {
 	if style == "" {
@@ -18,22 +86,27 @@ func bootstrap(style string) {
 		return
 	}
 
-	files, err := AssetDir(style)
+	styleFiles, err := findStyle(style)
 	if err != nil {
 		log.Fatalf("Failed to fetch style %s: %s\n", style, err)
 	}
-	exists := []string{}
-	for _, filename := range files {
-		if filename == "description.txt" {
-			continue
-		}
-		if strings.HasSuffix(filename, ".go.tpl") {
-			filename = strings.TrimSuffix(filename, ".tpl")
+	entries, err := fs.ReadDir(styleFiles, ".")
+	if err != nil {
+		log.Fatalf("Failed to fetch style %s: %s\n", style, err)
+	}
+	files := []string{}
+	for _, entry := range entries {
+		if !entry.IsDir() && bootstrapFilename(entry.Name()) != "" {
+			files = append(files, entry.Name())
 		}
+	}
 
-		_, err = os.Stat(filename)
+	funcs := mro.NewRenderer(mro.Config{}, mro.Result{}).Funcs()
+	exists := []string{}
+	for _, filename := range files {
+		_, err = os.Stat(bootstrapFilename(filename))
 		if err == nil {
-			exists = append(exists, filename)
+			exists = append(exists, bootstrapFilename(filename))
 		}
 	}
 	if len(exists) > 0 {
@@ -41,17 +114,13 @@ func bootstrap(style string) {
 	}
 
 	for _, filename := range files {
-		if filename == "description.txt" {
-			continue
-		}
-
-		content, err := Asset(style + "/" + filename)
+		content, err := fs.ReadFile(styleFiles, filename)
 		if err != nil {
-			log.Fatalf("Failed to load embedded asset: %s\n", err)
+			log.Fatalf("Failed to read %s from style %s: %s\n", filename, style, err)
 		}
 
 		if strings.HasSuffix(filename, ".mrotpl") {
-			outfile := strings.TrimSuffix(filename, ".mrotpl")
+			outfile := bootstrapFilename(filename)
 			tpl, err := template.New("global").Funcs(funcs).Delims("[[", "]]").Parse(string(content))
 			if err != nil {
 				log.Fatalf("failed to parse template %s: %s", filename, err)
@@ -69,7 +138,6 @@ func bootstrap(style string) {
 				log.Fatalf("failed to execute template %s: %s", filename, err)
 			}
 			f.Close()
-			tidyFile(outfile)
 			continue
 		}
 
@@ -81,15 +149,34 @@ func bootstrap(style string) {
 }
 
 func listStyles() {
-	allFiles := AssetNames()
-	fmt.Printf("Run \"mro --bootstrap <style>\" with one of these styles to get started\n\n")
-	for _, filename := range allFiles {
-		if strings.HasSuffix(filename, "/description.txt") {
-			content, err := Asset(filename)
+	fmt.Printf("Run \"mro --bootstrap <style>\" with one of these styles to get started\n")
+	seen := map[string]bool{}
+	for _, source := range styleSources() {
+		entries, err := fs.ReadDir(source.styles, ".")
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			log.Printf("Failed to read styles from %s: %s\n", source.name, err)
+			continue
+		}
+		descriptions := []string{}
+		for _, entry := range entries {
+			if !entry.IsDir() || seen[entry.Name()] {
+				continue
+			}
+			content, err := fs.ReadFile(source.styles, path.Join(entry.Name(), "description.txt"))
 			if err != nil {
-				log.Fatalf("Failed to read asset %s: %s\n", filename, err)
+				// Not a style
+				continue
 			}
-			fmt.Println(string(content))
+			seen[entry.Name()] = true
+			descriptions = append(descriptions, strings.TrimRight(string(content), "\n"))
+		}
+		if len(descriptions) == 0 {
+			continue
 		}
+		fmt.Printf("\n%s:\n\n%s\n", source.name, strings.Join(descriptions, "\n\n"))
 	}
+	fmt.Printf("\nor with the path to a style directory, e.g. \"mro --bootstrap ./mystyle\"\n")
 }
@@ -0,0 +1,183 @@
+package {{.Param.package}}
+
+import (
+    "database/sql"
+    "database/sql/driver"
+    "encoding/binary"
+    "encoding/json"
+    "errors"
+    "reflect"
+    "strings"
+    "time"
+    "net"
+    "github.com/jackc/pgx/pgtype"
+    "github.com/lib/pq"
+{{- range .Imports}}
+    "{{.}}"
+{{- end}}
+)
+
+//  {{$goname := goname .Composite.Name}}{{$goname}} represents the {{.Composite.Name}} composite type
+type {{$goname}} struct { {{- range $f := .Composite.Fields}}
+  {{goname $f.Name}} {{$f.GoType}} `json:"{{$f.Name}}" schema:"{{$f.Name}}"`{{end}}
+}
+
+// DecodeBinary satisfies pgtype.BinaryDecoder
+func (c *{{$goname}}) DecodeBinary(ci *pgtype.ConnInfo, src []byte) error {
+    if src == nil {
+        return errors.New("cannot decode NULL into {{$goname}}")
+    }
+    dsts := []interface{}{ {{- join (gonames .Composite.Fields "&c.") ", " -}} }
+    if len(src) < 4 || int(int32(binary.BigEndian.Uint32(src))) != len(dsts) {
+        return errors.New("wrong number of fields for {{$goname}}")
+    }
+    rp := 4
+    for _, dst := range dsts {
+        if len(src[rp:]) < 8 {
+            return errors.New("{{$goname}} record incomplete")
+        }
+        oid := pgtype.OID(binary.BigEndian.Uint32(src[rp:]))
+        fieldLen := int(int32(binary.BigEndian.Uint32(src[rp+4:])))
+        rp += 8
+        var field []byte
+        if fieldLen >= 0 {
+            if len(src[rp:]) < fieldLen {
+                return errors.New("{{$goname}} record incomplete")
+            }
+            field = src[rp : rp+fieldLen]
+            rp += fieldLen
+        }
+
+        if decoder, ok := dst.(pgtype.BinaryDecoder); ok {
+            err := decoder.DecodeBinary(ci, field)
+            if err != nil {
+                return err
+            }
+            continue
+        }
+
+        // Decode into the pgtype value for the field, then assign from that.
+        // Types without a binary format, such as enums, are sent as text.
+        var value pgtype.Value = &pgtype.GenericText{}
+        if dt, ok := ci.DataTypeForOID(oid); ok {
+            if _, ok := dt.Value.(pgtype.BinaryDecoder); ok {
+                value = reflect.New(reflect.ValueOf(dt.Value).Elem().Type()).Interface().(pgtype.Value)
+            }
+        }
+        var err error
+        if decoder, ok := value.(pgtype.BinaryDecoder); ok {
+            err = decoder.DecodeBinary(ci, field)
+        } else {
+            err = value.(pgtype.TextDecoder).DecodeText(ci, field)
+        }
+        if err != nil {
+            return err
+        }
+        if scanner, ok := dst.(sql.Scanner); ok {
+            sqlValue, err := pgtype.DatabaseSQLValue(ci, value)
+            if err != nil {
+                return err
+            }
+            err = scanner.Scan(sqlValue)
+        } else {
+            err = value.AssignTo(dst)
+        }
+        if err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// EncodeText satisfies pgtype.TextEncoder
+func (c {{$goname}}) EncodeText(ci *pgtype.ConnInfo, buf []byte) ([]byte, error) {
+    oids := []pgtype.OID{ {{- range $i, $f := .Composite.Fields}}{{if $i}}, {{end}}{{$f.TypeID}}{{end -}} }
+    srcs := []interface{}{ {{- join (gonames .Composite.Fields "c.") ", " -}} }
+    buf = append(buf, '(')
+    for i, src := range srcs {
+        if i > 0 {
+            buf = append(buf, ',')
+        }
+        if valuer, ok := src.(driver.Valuer); ok {
+            var err error
+            src, err = valuer.Value()
+            if err != nil {
+                return nil, err
+            }
+        }
+        if src == nil {
+            // An empty field is NULL
+            continue
+        }
+
+        encoder, ok := src.(pgtype.TextEncoder)
+        if !ok {
+            // Convert to the pgtype value for the field
+            var value pgtype.Value = &pgtype.GenericText{}
+            if dt, ok := ci.DataTypeForOID(oids[i]); ok {
+                value = reflect.New(reflect.ValueOf(dt.Value).Elem().Type()).Interface().(pgtype.Value)
+            }
+            err := value.Set(src)
+            if err != nil {
+                return nil, err
+            }
+            encoder, ok = value.(pgtype.TextEncoder)
+            if !ok {
+                return nil, errors.New("can't encode field of {{$goname}} as text")
+            }
+        }
+        text, err := encoder.EncodeText(ci, nil)
+        if err != nil {
+            return nil, err
+        }
+        if text == nil {
+            continue
+        }
+        buf = append(buf, '"')
+        buf = append(buf, strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(string(text))...)
+        buf = append(buf, '"')
+    }
+    return append(buf, ')'), nil
+}
+
+// Null{{$goname}} represents a {{.Composite.Name}} that may be null
+type Null{{$goname}} struct {
+    {{$goname}} {{$goname}}
+    Valid bool // Valid is true if {{$goname}} is not NULL
+}
+
+// DecodeBinary satisfies pgtype.BinaryDecoder
+func (n *Null{{$goname}}) DecodeBinary(ci *pgtype.ConnInfo, src []byte) error {
+    if src == nil {
+        *n = Null{{$goname}}{}
+        return nil
+    }
+    n.Valid = true
+    return n.{{$goname}}.DecodeBinary(ci, src)
+}
+
+// EncodeText satisfies pgtype.TextEncoder
+func (n Null{{$goname}}) EncodeText(ci *pgtype.ConnInfo, buf []byte) ([]byte, error) {
+    if !n.Valid {
+        return nil, nil
+    }
+    return n.{{$goname}}.EncodeText(ci, buf)
+}
+
+// MarshalJSON for making JSON, with null for a null value
+func (n Null{{$goname}}) MarshalJSON() ([]byte, error) {
+    if !n.Valid {
+        return []byte("null"), nil
+    }
+    return json.Marshal(n.{{$goname}})
+}
+
+// UnmarshalJSON for hydrating from json
+func (n *Null{{$goname}}) UnmarshalJSON(data []byte) error {
+    if string(data) == "null" {
+        *n = Null{{$goname}}{}
+        return nil
+    }
+    n.Valid = true
+    return json.Unmarshal(data, &n.{{$goname}})
+}
@@ -0,0 +1,62 @@
+package {{.Param.package}}
+
+import (
+    "database/sql"
+    "database/sql/driver"
+    "encoding/json"
+    "time"
+    "net"
+    "github.com/jackc/pgx/pgtype"
+{{- range .Imports}}
+    "{{.}}"
+{{- end}}
+)
+
+//  {{$goname := goname .Domain.Name}}{{$goname}} represents the {{.Domain.Name}} domain, based on {{.Domain.BaseType}}
+{{- range $check := .Domain.Checks}}
+//    {{$check}}
+{{- end}}
+type {{$goname}} {{.Domain.GoType}}
+
+// Null{{$goname}} represents a {{.Domain.Name}} that may be null
+type Null{{$goname}} struct {
+    {{$goname}} {{$goname}}
+    Valid bool // Valid is true if {{$goname}} is not NULL
+}
+
+// Value satisfies sql/driver.Valuer
+func (n Null{{$goname}}) Value() (driver.Value, error) {
+    if !n.Valid {
+        return nil, nil
+    }
+    return driver.DefaultParameterConverter.ConvertValue({{.Domain.GoType}}(n.{{$goname}}))
+}
+
+// Scan satisfies sql.Scanner
+func (n *Null{{$goname}}) Scan(src interface{}) error {
+    var v sql.Null[{{.Domain.GoType}}]
+    err := v.Scan(src)
+    if err != nil {
+        return err
+    }
+    n.{{$goname}}, n.Valid = {{$goname}}(v.V), v.Valid
+    return nil
+}
+
+// MarshalJSON for making JSON, with null for a null value
+func (n Null{{$goname}}) MarshalJSON() ([]byte, error) {
+    if !n.Valid {
+        return []byte("null"), nil
+    }
+    return json.Marshal(n.{{$goname}})
+}
+
+// UnmarshalJSON for hydrating from json
+func (n *Null{{$goname}}) UnmarshalJSON(data []byte) error {
+    if string(data) == "null" {
+        *n = Null{{$goname}}{}
+        return nil
+    }
+    n.Valid = true
+    return json.Unmarshal(data, &n.{{$goname}})
+}
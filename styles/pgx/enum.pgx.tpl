@@ -11,7 +11,7 @@ type {{$goname}} uint16
 
 const ({{range $i, $label := .Enum.Labels}}
   // {{$label}}
-  {{$goname}}{{goname $label}}{{if eq $i 0}} = iota{{end}}
+  {{$goname}}{{goname $label}}{{if eq $i 0}} {{$goname}} = iota{{end}}
 {{- end}}
 )
 
@@ -49,11 +49,13 @@ func (e {{$goname}}) Value() (driver.Value, error) {
 
 // Scan satisfies sql.Scanner
 func (e *{{$goname}}) Scan(src interface{}) error {
-    buf, ok := src.([]byte)
-    if !ok {
-        return errors.New("invalid {{$goname}}")
+    switch v := src.(type) {
+    case []byte:
+        return e.UnmarshalText(v)
+    case string:
+        return e.UnmarshalText([]byte(v))
     }
-    return e.UnmarshalText(buf)
+    return errors.New("invalid {{$goname}}")
 }
 
 // Valid{{$goname}} provides all the valid enum labels
@@ -67,6 +69,53 @@ func (e {{$goname}}) MarshalJSON() ([]byte, error) {
 }
 
 // UnmarshalJSON for hydrating from json
-func (e *{{$goname}}) UhnmarshalJSON(data []byte) error {
-    return e.UnmarshalText(data)
+func (e *{{$goname}}) UnmarshalJSON(data []byte) error {
+    var s string
+    err := json.Unmarshal(data, &s)
+    if err != nil {
+        return err
+    }
+    return e.UnmarshalText([]byte(s))
+}
+
+// Null{{$goname}} represents a {{.Enum.Name}} that may be null
+type Null{{$goname}} struct {
+    {{$goname}} {{$goname}}
+    Valid bool // Valid is true if {{$goname}} is not NULL
+}
+
+// Value satisfies sql/driver.Valuer
+func (n Null{{$goname}}) Value() (driver.Value, error) {
+    if !n.Valid {
+        return nil, nil
+    }
+    return n.{{$goname}}.Value()
+}
+
+// Scan satisfies sql.Scanner
+func (n *Null{{$goname}}) Scan(src interface{}) error {
+    if src == nil {
+        n.{{$goname}}, n.Valid = 0, false
+        return nil
+    }
+    n.Valid = true
+    return n.{{$goname}}.Scan(src)
+}
+
+// MarshalJSON for making JSON, with null for a null value
+func (n Null{{$goname}}) MarshalJSON() ([]byte, error) {
+    if !n.Valid {
+        return []byte("null"), nil
+    }
+    return n.{{$goname}}.MarshalJSON()
+}
+
+// UnmarshalJSON for hydrating from json
+func (n *Null{{$goname}}) UnmarshalJSON(data []byte) error {
+    if string(data) == "null" {
+        n.{{$goname}}, n.Valid = 0, false
+        return nil
+    }
+    n.Valid = true
+    return n.{{$goname}}.UnmarshalJSON(data)
 }
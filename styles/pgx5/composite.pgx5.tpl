@@ -0,0 +1,100 @@
+package {{.Param.package}}
+
+import (
+    "database/sql"
+    "encoding/json"
+    "errors"
+    "time"
+    "net"
+    "net/netip"
+    "github.com/jackc/pgx/v5/pgtype"
+{{- range .Imports}}
+    "{{.}}"
+{{- end}}
+)
+
+//  {{$goname := goname .Composite.Name}}{{$goname}} represents the {{.Composite.Name}} composite type
+type {{$goname}} struct { {{- range $f := .Composite.Fields}}
+  {{goname $f.Name}} {{$f.GoType}} `json:"{{$f.Name}}" schema:"{{$f.Name}}"`{{end}}
+}
+{{$typename := maybequote .Composite.Name}}{{if .Composite.Schema}}{{$typename = printf "%s.%s" (maybequote .Composite.Schema) $typename}}{{end}}
+func init() {
+    // RegisterTypes needs to know about {{.Composite.Name}}
+    mroTypes = append(mroTypes, {{printf "%q" $typename}})
+}
+
+// ScanNull satisfies pgtype.CompositeIndexScanner
+func (c *{{$goname}}) ScanNull() error {
+    return errors.New("cannot scan NULL into {{$goname}}")
+}
+
+// ScanIndex satisfies pgtype.CompositeIndexScanner
+func (c *{{$goname}}) ScanIndex(i int) any {
+    switch i { {{- range $i, $f := .Composite.Fields}}
+    case {{$i}}:
+        return &c.{{goname $f.Name}}
+{{- end}}
+    }
+    return nil
+}
+
+// IsNull satisfies pgtype.CompositeIndexGetter
+func (c {{$goname}}) IsNull() bool {
+    return false
+}
+
+// Index satisfies pgtype.CompositeIndexGetter
+func (c {{$goname}}) Index(i int) any {
+    switch i { {{- range $i, $f := .Composite.Fields}}
+    case {{$i}}:
+        return c.{{goname $f.Name}}
+{{- end}}
+    }
+    return nil
+}
+
+// Null{{$goname}} represents a {{.Composite.Name}} that may be null
+type Null{{$goname}} struct {
+    {{$goname}} {{$goname}}
+    Valid bool // Valid is true if {{$goname}} is not NULL
+}
+
+// ScanNull satisfies pgtype.CompositeIndexScanner
+func (n *Null{{$goname}}) ScanNull() error {
+    *n = Null{{$goname}}{}
+    return nil
+}
+
+// ScanIndex satisfies pgtype.CompositeIndexScanner
+func (n *Null{{$goname}}) ScanIndex(i int) any {
+    n.Valid = true
+    return n.{{$goname}}.ScanIndex(i)
+}
+
+// IsNull satisfies pgtype.CompositeIndexGetter
+func (n Null{{$goname}}) IsNull() bool {
+    return !n.Valid
+}
+
+// Index satisfies pgtype.CompositeIndexGetter
+func (n Null{{$goname}}) Index(i int) any {
+    return n.{{$goname}}.Index(i)
+}
+
+// MarshalJSON for making JSON, with null for a null value
+func (n Null{{$goname}}) MarshalJSON() ([]byte, error) {
+    if !n.Valid {
+        return []byte("null"), nil
+    }
+    return json.Marshal(n.{{$goname}})
+}
+
+// UnmarshalJSON for hydrating from json
+func (n *Null{{$goname}}) UnmarshalJSON(data []byte) error {
+    if string(data) == "null" {
+        *n = Null{{$goname}}{}
+        return nil
+    }
+    n.Valid = true
+    return json.Unmarshal(data, &n.{{$goname}})
+}
@@ -0,0 +1,127 @@
+package {{.Param.package}}
+
+import (
+    "encoding/json"
+    "errors"
+    "database/sql/driver"
+)
+
+//  {{$goname := goname .Enum.Name}}{{$goname}} represents the {{.Enum.Name}} enum
+type {{$goname}} uint16
+
+{{$typename := maybequote .Enum.Name}}{{if .Enum.Schema}}{{$typename = printf "%s.%s" (maybequote .Enum.Schema) $typename}}{{end}}
+func init() {
+    // RegisterTypes needs to know about {{.Enum.Name}}
+    mroTypes = append(mroTypes, {{printf "%q" $typename}})
+}
+
+const ({{range $i, $label := .Enum.Labels}}
+  // {{$label}}
+  {{$goname}}{{goname $label}}{{if eq $i 0}} {{$goname}} = iota{{end}}
+{{- end}}
+)
+
+// String returns the string value of the label
+func (e {{$goname}}) String() string {
+  switch e { {{- range $label := .Enum.Labels}}
+    case {{$goname}}{{goname $label}}:
+      return "{{$label}}"
+{{end}}
+  }
+  return ""
+}
+
+// MarshalText marshals {{$goname}} into text
+func (e {{$goname}}) MarshalText() ([]byte, error) {
+  return []byte(e.String()), nil
+}
+
+// UnmarshalText unmarshals {{$goname}} from text
+func (e *{{$goname}}) UnmarshalText(text []byte) error {
+    switch string(text) { {{- range $label := .Enum.Labels}}
+        case "{{$label}}":
+            *e = {{$goname}}{{goname $label}}
+{{end}}
+        default:
+            return errors.New("invalid {{$goname}}")
+    }
+    return nil
+}
+
+// Value satisfies sql/driver.Valuer
+func (e {{$goname}}) Value() (driver.Value, error) {
+    return e.String(), nil
+}
+
+// Scan satisfies sql.Scanner
+func (e *{{$goname}}) Scan(src interface{}) error {
+    switch v := src.(type) {
+    case []byte:
+        return e.UnmarshalText(v)
+    case string:
+        return e.UnmarshalText([]byte(v))
+    }
+    return errors.New("invalid {{$goname}}")
+}
+
+// Valid{{$goname}} provides all the valid enum labels
+func Valid{{$goname}}() []string {
+    return []string{ {{- range $i, $label := .Enum.Labels}}{{if ne $i 0}}, {{end}}"{{$label}}"{{end -}} }
+}
+
+// MarshalJSON for making JSON
+func (e {{$goname}}) MarshalJSON() ([]byte, error) {
+    return json.Marshal(e.String())
+}
+
+// UnmarshalJSON for hydrating from json
+func (e *{{$goname}}) UnmarshalJSON(data []byte) error {
+    var s string
+    err := json.Unmarshal(data, &s)
+    if err != nil {
+        return err
+    }
+    return e.UnmarshalText([]byte(s))
+}
+
+// Null{{$goname}} represents a {{.Enum.Name}} that may be null
+type Null{{$goname}} struct {
+    {{$goname}} {{$goname}}
+    Valid bool // Valid is true if {{$goname}} is not NULL
+}
+
+// Value satisfies sql/driver.Valuer
+func (n Null{{$goname}}) Value() (driver.Value, error) {
+    if !n.Valid {
+        return nil, nil
+    }
+    return n.{{$goname}}.Value()
+}
+
+// Scan satisfies sql.Scanner
+func (n *Null{{$goname}}) Scan(src interface{}) error {
+    if src == nil {
+        n.{{$goname}}, n.Valid = 0, false
+        return nil
+    }
+    n.Valid = true
+    return n.{{$goname}}.Scan(src)
+}
+
+// MarshalJSON for making JSON, with null for a null value
+func (n Null{{$goname}}) MarshalJSON() ([]byte, error) {
+    if !n.Valid {
+        return []byte("null"), nil
+    }
+    return n.{{$goname}}.MarshalJSON()
+}
+
+// UnmarshalJSON for hydrating from json
+func (n *Null{{$goname}}) UnmarshalJSON(data []byte) error {
+    if string(data) == "null" {
+        n.{{$goname}}, n.Valid = 0, false
+        return nil
+    }
+    n.Valid = true
+    return n.{{$goname}}.UnmarshalJSON(data)
+}
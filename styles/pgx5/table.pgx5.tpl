@@ -0,0 +1,363 @@
+package {{.Param.package}}
+{{ $stable := printf "%s.%s" (maybequote .Table.Schema) (maybequote .Table.Name) }}
+// {{ $stable }}
+
+import (
+    "context"
+    "encoding/json"
+    "strconv"
+    "strings"
+    "time"
+    "net"
+    "net/netip"
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgtype"
+    "database/sql"
+{{- range .Imports}}
+    "{{.}}"
+{{- end}}
+)
+
+{{- $wfields := writable .Table.Fields}}
+{{- $sfields := hasdefault $wfields}}
+{{- /* Setters are only needed by InsertDefaults and UpdateChanged */}}
+{{- $setters := and $wfields (or (hasdefault .Table.Fields) .Table.PrimaryFields)}}
+//  {{$goname := goname .Table.Alias}}{{$goname}} represents a row from {{.Table.Name}}
+type {{$goname}} struct { {{- range $f := .Table.Fields}}
+  {{goname $f.Name}} {{$f.GoType}} `json:"{{$f.Name}}" schema:"{{$f.Name}}"`{{end}}
+{{- if $setters}}
+  mroSet [{{len $wfields}}]bool // which columns have been set with their setters{{end}}
+}
+
+const {{$goname}}Columns = `{{join (maybequote .Table.Fields) ", "}}`
+
+{{if .Table.IDField.Name}}
+// Insert a {{$goname}} into the database
+func (t *{{$goname}}) Insert(ctx context.Context, db MRODB) error {
+    {{- $dfields := writable (excludefield .Table.Fields .Table.IDField)}}
+    const query = `insert into {{ $stable }} (` +
+      `{{join (maybequote $dfields) ", "}}` +
+      `) values (` +
+      `{{join (bindvars $dfields) ", "}}` +
+      `) returning {{maybequote .Table.IDField.Name}}`
+    err := db.QueryRow(ctx, query, {{join (gonames $dfields "t.") ", "}}).Scan(&t.{{goname .Table.IDField.Name}})
+    if err != nil {
+        return err
+    }
+{{- if $setters}}
+    t.mroSet = [{{len $wfields}}]bool{}
+{{- end}}
+    return nil
+}
+{{else}}{{/* IDField.Name */}}
+// Insert a {{$goname}} into the database
+func (t *{{$goname}}) Insert(ctx context.Context, db MRODB) error {
+    {{- $dfields := writable .Table.Fields}}
+    const query = `insert into {{ $stable }} (` +
+      `{{join (maybequote $dfields) ", "}}` +
+      `) values (` +
+      `{{join (bindvars $dfields) ", "}}` +
+      `)`
+    _, err := db.Exec(ctx, query, {{join (gonames $dfields "t.") ", "}})
+    if err != nil {
+        return err
+    }
+{{- if $setters}}
+    t.mroSet = [{{len $wfields}}]bool{}
+{{- end}}
+    return nil
+}
+{{end}}{{/* IDField.Name */}}
+{{if $setters}}{{range $i, $f := $wfields}}
+// Set{{goname $f.Name}} sets {{goname $f.Name}}, and records that it's been set
+// for InsertDefaults and UpdateChanged
+func (t *{{$goname}}) Set{{goname $f.Name}}(v {{$f.GoType}}) {
+    t.{{goname $f.Name}} = v
+    t.mroSet[{{$i}}] = true
+}
+{{end}}{{end}}{{/* setters */}}
+{{if hasdefault .Table.Fields}}
+{{- $ffields := excludefields $wfields $sfields}}
+{{- $rfields := excludefields .Table.Fields $wfields}}
+// InsertDefaults inserts a {{$goname}} into the database. Columns with a
+// default are left for the database to fill in, unless they've been set
+// with their SetX method, and the values it chooses are read back.
+func (t *{{$goname}}) InsertDefaults(ctx context.Context, db MRODB) error {
+    columns := []string{ {{- range $i, $f := $ffields}}{{if $i}}, {{end}}`{{maybequote $f.Name}}`{{end -}} }
+    values := []interface{}{ {{- join (gonames $ffields "t.") ", " -}} }
+    returning := []string{ {{- range $i, $f := $rfields}}{{if $i}}, {{end}}`{{maybequote $f.Name}}`{{end -}} }
+    dests := []interface{}{ {{- join (gonames $rfields "&t.") ", " -}} }
+{{- range $f := $sfields}}
+    if t.mroSet[{{fieldindex $wfields $f}}] {
+        columns = append(columns, `{{maybequote $f.Name}}`)
+        values = append(values, t.{{goname $f.Name}})
+    } else {
+        returning = append(returning, `{{maybequote $f.Name}}`)
+        dests = append(dests, &t.{{goname $f.Name}})
+    }
+{{- end}}
+
+    query := `insert into {{ $stable }}`
+    if len(columns) == 0 {
+        query += ` default values`
+    } else {
+        bindvars := make([]string, len(columns))
+        for i := range bindvars {
+            bindvars[i] = "$" + strconv.Itoa(i+1)
+        }
+        query += ` (` + strings.Join(columns, ", ") + `) values (` + strings.Join(bindvars, ", ") + `)`
+    }
+    var err error
+    if len(returning) == 0 {
+        _, err = db.Exec(ctx, query, values...)
+    } else {
+        query += ` returning ` + strings.Join(returning, ", ")
+        err = db.QueryRow(ctx, query, values...).Scan(dests...)
+    }
+    if err != nil {
+        return err
+    }
+{{- if $setters}}
+    t.mroSet = [{{len $wfields}}]bool{}
+{{- end}}
+    return nil
+}
+{{end}}{{/* hasdefault */}}
+
+{{if .Table.PrimaryFields}}
+{{- $kfields := .Table.PrimaryFields}}
+{{- $dfields := writable (excludefields .Table.Fields $kfields)}}
+{{- $ifields := writable .Table.Fields $kfields}}
+{{- if $dfields}}
+// Update an existing {{$goname}} in the database
+func (t *{{$goname}}) Update(ctx context.Context, db MRODB) error {
+    const query = `update {{$stable}} set ` +
+      `{{join (assign $dfields (bindvars $dfields)) ", "}}` +
+      ` where {{join (assign $kfields (bindvarsfrom $kfields (inc (len $dfields)))) " and "}}`
+
+    _, err := db.Exec(ctx, query, {{join (gonames $dfields "t.") ", "}}, {{join (gonames $kfields "t.") ", "}})
+    return err
+}
+
+// UpdateChanged updates only the columns of an existing {{$goname}} that
+// have been changed with their SetX method. It does nothing if none have.
+func (t *{{$goname}}) UpdateChanged(ctx context.Context, db MRODB) error {
+    sets := []string{}
+    values := []interface{}{}
+{{- range $f := $dfields}}
+    if t.mroSet[{{fieldindex $wfields $f}}] {
+        values = append(values, t.{{goname $f.Name}})
+        sets = append(sets, `{{maybequote $f.Name}} = $` + strconv.Itoa(len(values)))
+    }
+{{- end}}
+    if len(sets) == 0 {
+        return nil
+    }
+
+    wheres := []string{}
+{{- range $f := $kfields}}
+    values = append(values, t.{{goname $f.Name}})
+    wheres = append(wheres, `{{maybequote $f.Name}} = $` + strconv.Itoa(len(values)))
+{{- end}}
+    query := `update {{$stable}} set ` + strings.Join(sets, ", ") + ` where ` + strings.Join(wheres, " and ")
+    _, err := db.Exec(ctx, query, values...)
+    if err != nil {
+        return err
+    }
+{{- range $f := $dfields}}
+    t.mroSet[{{fieldindex $wfields $f}}] = false
+{{- end}}
+    return nil
+}
+{{end}}{{/* dfields */}}
+
+// Upsert a {{$goname}} into the database
+func (t *{{$goname}}) Upsert(ctx context.Context, db MRODB) error {
+    const query = `insert into {{ $stable }} (` +
+      `{{join (maybequote $ifields) ", "}}` +
+      `){{if identityalways $kfields}} overriding system value{{end}} values (` +
+      `{{join (bindvars $ifields) ", "}}` +
+      `) on conflict ({{join (maybequote $kfields) ", "}}) do {{if $dfields}}update set ` +
+      `{{join (assign $dfields (prefix (maybequote $dfields) "EXCLUDED.")) ", "}}`
+      {{- else}}nothing`{{end}}
+
+    _, err := db.Exec(ctx, query, {{join (gonames $ifields "t.") ", "}})
+    return err
+}
+
+// Delete a {{$goname}} from the database
+func (t *{{$goname}}) Delete(ctx context.Context, db MRODB) error {
+    const query = `delete from {{ $stable }} where {{join (assign $kfields (bindvars $kfields)) " and "}}`
+
+    _, err := db.Exec(ctx, query, {{join (gonames $kfields "t.") ", "}})
+    return err
+}
+{{end}}{{/* PrimaryFields */}}
+
+func All{{$goname}}(ctx context.Context, db MRODB) ([]{{$goname}}, error) {
+    const query = `select ` +
+      `{{join (maybequote .Table.Fields) ", "}}` +
+      ` from {{$stable}}`
+
+    q, err := db.Query(ctx, query)
+    if err != nil {
+        return nil, err
+    }
+    return pgx.CollectRows(q, pgx.RowToStructByPos[{{$goname}}])
+}
+
+func UnmarshalOne{{$goname}}(row pgx.Row, r *{{$goname}}) error {
+    return row.Scan({{join (gonames .Table.Fields "&r.") ", "}})
+}
+
+func Unmarshal{{$goname}}(q pgx.Rows) ([]{{$goname}}, error) {
+    return pgx.CollectRows(q, pgx.RowToStructByPos[{{$goname}}])
+}
+
+{{range $fk := .Table.ForeignKeys}}{{if $fk.ParentMethod}}
+{{- $pt := table $fk.ForeignSchema $fk.ForeignTable}}
+{{- $ptype := qualify $fk.ForeignPackage (goname $pt.Alias)}}
+{{- $pcols := columns $pt $fk.ForeignColumns}}
+{{- $ccols := columns $.Table $fk.Columns}}
+// {{$fk.ParentMethod}} loads the {{$pt.Name}} row that this {{$goname}} refers to
+// with {{$fk.Name}}
+func (t *{{$goname}}) {{$fk.ParentMethod}}(ctx context.Context, db MRODB) ({{$ptype}}, error) {
+    const query = `select {{join (maybequote $pt.Fields) ", "}}` +
+      ` from {{maybequote $pt.Schema}}.{{maybequote $pt.Name}}` +
+      ` where {{join (assign $pcols (bindvars $pcols)) " and "}}`
+    var row {{$ptype}}
+    err := {{qualify $fk.ForeignPackage (printf "UnmarshalOne%s" (goname $pt.Alias))}}(db.QueryRow(ctx, query, {{join (gonames $ccols "t.") ", "}}), &row)
+    return row, err
+}
+{{if $fk.BatchFunction}}
+{{- $pcol := index $pcols 0}}{{$ccol := index $ccols 0}}
+// {{$fk.BatchFunction}} loads the {{$pt.Name}} rows that each of rows refers
+// to with {{$fk.Name}}, keyed by {{$pcol.Name}}
+func {{$fk.BatchFunction}}(ctx context.Context, db MRODB, rows []{{$goname}}) (map[{{$ccol.GoType}}]{{$ptype}}, error) {
+    keys := make([]{{$ccol.GoType}}, len(rows))
+    for i, r := range rows {
+        keys[i] = r.{{goname $ccol.Name}}
+    }
+    const query = `select {{join (maybequote $pt.Fields) ", "}}` +
+      ` from {{maybequote $pt.Schema}}.{{maybequote $pt.Name}}` +
+      ` where {{maybequote $pcol.Name}} = any($1)`
+    q, err := db.Query(ctx, query, keys)
+    if err != nil {
+        return nil, err
+    }
+    defer q.Close()
+    result := map[{{$ccol.GoType}}]{{$ptype}}{}
+    for q.Next() {
+        var row {{$ptype}}
+        err = q.Scan({{join (gonames $pt.Fields "&row.") ", "}})
+        if err != nil {
+            return nil, err
+        }
+        result[row.{{goname $pcol.Name}}] = row
+    }
+    return result, q.Err()
+}
+{{end}}{{/* BatchFunction */}}
+{{end}}{{end}}{{/* ForeignKeys */}}
+
+{{range $ref := .Table.References}}
+{{- $ct := table $ref.Schema $ref.Table}}
+{{- $ctype := goname $ct.Alias}}
+{{- $ccols := columns $ct $ref.Columns}}
+{{- $pcols := columns $.Table $ref.ForeignColumns}}
+// {{$ref.ChildrenMethod}} loads the {{$ct.Name}} rows that refer to this
+// {{$goname}} with {{$ref.Name}}
+func (t *{{$goname}}) {{$ref.ChildrenMethod}}(ctx context.Context, db MRODB) ([]{{$ctype}}, error) {
+    const query = `select {{join (maybequote $ct.Fields) ", "}}` +
+      ` from {{maybequote $ct.Schema}}.{{maybequote $ct.Name}}` +
+      ` where {{join (assign $ccols (bindvars $ccols)) " and "}}`
+    q, err := db.Query(ctx, query, {{join (gonames $pcols "t.") ", "}})
+    if err != nil {
+        return nil, err
+    }
+    defer q.Close()
+    return Unmarshal{{$ctype}}(q)
+}
+{{if $ref.BatchFunction}}
+{{- $pcol := index $pcols 0}}{{$ccol := index $ccols 0}}
+// {{$ref.BatchFunction}} loads the {{$ct.Name}} rows that refer to each of
+// rows with {{$ref.Name}}, keyed by {{$pcol.Name}}
+func {{$ref.BatchFunction}}(ctx context.Context, db MRODB, rows []{{$goname}}) (map[{{$pcol.GoType}}][]{{$ctype}}, error) {
+    keys := make([]{{$pcol.GoType}}, len(rows))
+    for i, r := range rows {
+        keys[i] = r.{{goname $pcol.Name}}
+    }
+    const query = `select {{join (maybequote $ct.Fields) ", "}}, {{maybequote $ccol.Name}}` +
+      ` from {{maybequote $ct.Schema}}.{{maybequote $ct.Name}}` +
+      ` where {{maybequote $ccol.Name}} = any($1)`
+    q, err := db.Query(ctx, query, keys)
+    if err != nil {
+        return nil, err
+    }
+    defer q.Close()
+    result := map[{{$pcol.GoType}}][]{{$ctype}}{}
+    for q.Next() {
+        var row {{$ctype}}
+        var key {{$pcol.GoType}}
+        err = q.Scan({{join (gonames $ct.Fields "&row.") ", "}}, &key)
+        if err != nil {
+            return nil, err
+        }
+        result[key] = append(result[key], row)
+    }
+    return result, q.Err()
+}
+{{end}}{{/* BatchFunction */}}
+{{end}}{{/* References */}}
+
+{{ $t := .Table }}
+{{range $q := .Table.Queries}}
+{{- $rowtype := $goname}}{{$rowfields := $t.Fields}}
+{{- if $q.ResultType}}{{$rowtype = $q.ResultType}}{{$rowfields = $q.Fields}}
+// {{$rowtype}} represents a row returned by {{$q.Name}}
+type {{$rowtype}} struct { {{- range $f := $q.Fields}}
+  {{goname $f.Name}} {{$f.GoType}} `json:"{{$f.Name}}" schema:"{{$f.Name}}"`{{end}}
+}
+{{end}}
+{{if $q.Exec}}
+// {{$q.Name}} runs
+//   {{$q.Query}}
+// and returns the number of rows affected
+func {{$q.Name}}(ctx context.Context, db MRODB{{range $p := $q.Parameters -}}
+, {{$p.Name}} {{$p.GoType}}
+{{- end}}) (int64, error) {
+  const query = `{{$q.Query}}`
+  mroTag, err := db.Exec(ctx, query, {{join (names $q.Parameters) ", "}})
+  if err != nil {
+      return 0, err
+  }
+  return mroTag.RowsAffected(), nil
+}
+{{else if $q.SingleRow}}
+// {{$q.Name}} returns the result of
+//   {{$q.Query}}
+{{if ne $q.Query $q.OriginalQuery}}//   (originally {{$q.OriginalQuery}}){{end}}
+func {{$q.Name}}(ctx context.Context, db MRODB{{range $p := $q.Parameters -}}
+, {{$p.Name}} {{$p.GoType}}
+{{- end}}) ({{$rowtype}}, error) {
+  const query = `{{$q.Query}}`
+  var row {{$rowtype}}
+  err := db.QueryRow(ctx, query, {{join (names $q.Parameters) ", "}}).Scan({{join (gonames $rowfields "&row.") ", "}})
+  return row, err
+}
+{{else}}
+// {{$q.Name}} returns the result of
+//   {{$q.Query}}
+{{if ne $q.Query $q.OriginalQuery}}//   (originally {{$q.OriginalQuery}}){{end}}
+func {{$q.Name}}(ctx context.Context, db MRODB{{range $p := $q.Parameters -}}
+, {{$p.Name}} {{$p.GoType}}
+{{- end}}) ([]{{$rowtype}}, error) {
+  const query = `{{$q.Query}}`
+  q, err := db.Query(ctx, query, {{join (names $q.Parameters) ", "}})
+  if err != nil {
+      return nil, err
+  }
+  return pgx.CollectRows(q, pgx.RowToStructByPos[{{$rowtype}}])
+}
+{{end}}
+{{end}}
\ No newline at end of file
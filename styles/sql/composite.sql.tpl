@@ -0,0 +1,85 @@
+package {{.Param.package}}
+
+import (
+    "database/sql"
+    "database/sql/driver"
+    "encoding/json"
+    "errors"
+    "time"
+{{- range .Imports}}
+    "{{.}}"
+{{- end}}
+)
+
+//  {{$goname := goname .Composite.Name}}{{$goname}} represents the {{.Composite.Name}} composite type
+type {{$goname}} struct { {{- range $f := .Composite.Fields}}
+  {{goname $f.Name}} {{$f.GoType}} `json:"{{$f.Name}}" schema:"{{$f.Name}}"`{{end}}
+}
+
+// Value satisfies sql/driver.Valuer
+func (c {{$goname}}) Value() (driver.Value, error) {
+    return mroRecord({{join (gonames .Composite.Fields "c.") ", "}})
+}
+
+// Scan satisfies sql.Scanner
+func (c *{{$goname}}) Scan(src interface{}) error {
+    if src == nil {
+        return errors.New("cannot scan NULL into {{$goname}}")
+    }
+    fields, err := mroFields(src)
+    if err != nil {
+        return err
+    }
+    if len(fields) != {{len .Composite.Fields}} {
+        return errors.New("wrong number of fields for {{$goname}}")
+    }
+{{- range $i, $f := .Composite.Fields}}
+    err = mroScanField(&c.{{goname $f.Name}}, fields[{{$i}}])
+    if err != nil {
+        return err
+    }
+{{- end}}
+    return nil
+}
+
+// Null{{$goname}} represents a {{.Composite.Name}} that may be null
+type Null{{$goname}} struct {
+    {{$goname}} {{$goname}}
+    Valid bool // Valid is true if {{$goname}} is not NULL
+}
+
+// Value satisfies sql/driver.Valuer
+func (n Null{{$goname}}) Value() (driver.Value, error) {
+    if !n.Valid {
+        return nil, nil
+    }
+    return n.{{$goname}}.Value()
+}
+
+// Scan satisfies sql.Scanner
+func (n *Null{{$goname}}) Scan(src interface{}) error {
+    if src == nil {
+        *n = Null{{$goname}}{}
+        return nil
+    }
+    n.Valid = true
+    return n.{{$goname}}.Scan(src)
+}
+
+// MarshalJSON for making JSON, with null for a null value
+func (n Null{{$goname}}) MarshalJSON() ([]byte, error) {
+    if !n.Valid {
+        return []byte("null"), nil
+    }
+    return json.Marshal(n.{{$goname}})
+}
+
+// UnmarshalJSON for hydrating from json
+func (n *Null{{$goname}}) UnmarshalJSON(data []byte) error {
+    if string(data) == "null" {
+        *n = Null{{$goname}}{}
+        return nil
+    }
+    n.Valid = true
+    return json.Unmarshal(data, &n.{{$goname}})
+}